@@ -0,0 +1,73 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// MaxBackoff caps the delay a Backoff grows to.
+const MaxBackoff = 5 * time.Minute
+
+// Backoff spaces out the polls of a watch. While the API pushes back, with a
+// 429 or an open circuit breaker, each poll doubles the delay up to
+// MaxBackoff; any other outcome resets it to the interval.
+type Backoff struct {
+	interval time.Duration
+	failures int
+}
+
+// NewBackoff returns a Backoff that polls every interval.
+func NewBackoff(interval time.Duration) *Backoff {
+	return &Backoff{interval: interval}
+}
+
+// Next returns how long to wait after a poll that returned err. A longer
+// Retry-After from the API wins.
+func (b *Backoff) Next(err error) time.Duration {
+	if !IsThrottled(err) {
+		b.failures = 0
+
+		return b.interval
+	}
+
+	b.failures++
+
+	delay := b.interval
+	for range b.failures {
+		delay *= 2
+		if delay >= MaxBackoff {
+			delay = max(MaxBackoff, b.interval)
+
+			break
+		}
+	}
+
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) {
+		delay = max(delay, time.Duration(rateErr.RetryAfter)*time.Second)
+	}
+
+	return delay
+}
+
+// IsThrottled reports whether err means the API asked to slow down: a rate
+// limit, or the circuit breaker refusing requests after repeated failures.
+func IsThrottled(err error) bool {
+	var (
+		rateErr    *RateLimitError
+		circuitErr *CircuitBreakerError
+		apiErr     *APIError
+	)
+
+	switch {
+	case err == nil:
+		return false
+	case errors.As(err, &rateErr), errors.As(err, &circuitErr), errors.Is(err, ErrRateLimited):
+		return true
+	case errors.As(err, &apiErr):
+		return apiErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	return false
+}
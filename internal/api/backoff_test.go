@@ -0,0 +1,55 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackoffNext(t *testing.T) {
+	b := NewBackoff(30 * time.Second)
+	throttled := fmt.Errorf("get departures: %w", &CircuitBreakerError{})
+
+	steps := []struct {
+		err  error
+		want time.Duration
+	}{
+		{nil, 30 * time.Second},
+		{errors.New("connection refused"), 30 * time.Second},
+		{throttled, time.Minute},
+		{&RateLimitError{}, 2 * time.Minute},
+		{throttled, 4 * time.Minute},
+		{throttled, MaxBackoff},
+		{throttled, MaxBackoff},
+		{nil, 30 * time.Second},
+		{&RateLimitError{RetryAfter: 600}, 10 * time.Minute},
+	}
+
+	for i, step := range steps {
+		if got := b.Next(step.err); got != step.want {
+			t.Errorf("step %d: Next(%v) = %s, want %s", i, step.err, got, step.want)
+		}
+	}
+}
+
+func TestIsThrottled(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("boom"), false},
+		{&APIError{StatusCode: 500}, false},
+		{&APIError{StatusCode: 429}, true},
+		{fmt.Errorf("wrapped: %w", &RateLimitError{RetryAfter: 5}), true},
+		{&CircuitBreakerError{}, true},
+		{ErrRateLimited, true},
+	}
+
+	for _, tt := range tests {
+		if got := IsThrottled(tt.err); got != tt.want {
+			t.Errorf("IsThrottled(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
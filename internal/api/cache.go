@@ -0,0 +1,79 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrNotCached is returned offline for requests that never succeeded online.
+var ErrNotCached = errors.New("no cached response; run the command once while online")
+
+// ResponseCache keeps the last successful response of each request on disk,
+// so it can be served when the network is down.
+type ResponseCache struct {
+	dir string
+}
+
+// NewResponseCache returns a cache that stores responses in dir.
+func NewResponseCache(dir string) *ResponseCache {
+	return &ResponseCache{dir: dir}
+}
+
+// Load returns the cached response for key and when it was fetched.
+func (c *ResponseCache) Load(key string) ([]byte, time.Time, error) {
+	path := c.path(key)
+
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("read cached response: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("stat cached response: %w", err)
+	}
+
+	return body, info.ModTime(), nil
+}
+
+// Store saves body as the response for key. The file is replaced
+// atomically, so a concurrent Load never sees half a response.
+func (c *ResponseCache) Store(key string, body []byte) error {
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return fmt.Errorf("ensure cache dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.dir, ".response-*")
+	if err != nil {
+		return fmt.Errorf("create cached response: %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("write cached response: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write cached response: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		return fmt.Errorf("save cached response: %w", err)
+	}
+
+	return nil
+}
+
+func (c *ResponseCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
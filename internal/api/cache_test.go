@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const cachedRealtime = `{"halteDoorkomsten": [{"haltenummer": 200144, "doorkomsten": [{
+	"entiteitnummer": 2, "lijnnummer": 1, "bestemming": "Flanders Expo",
+	"dienstregelingTijdstip": "2026-10-18T08:00:00", "real-timeTijdstip": "2026-10-18T08:04:00",
+	"predictionStatussen": ["REALTIME"]}]}]}`
+
+func TestResponseCacheRoundTrip(t *testing.T) {
+	cache := NewResponseCache(t.TempDir())
+
+	if _, _, err := cache.Load("a"); err == nil {
+		t.Fatal("Load of a missing key succeeded")
+	}
+
+	if err := cache.Store("a", []byte(`{"x": 1}`)); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+
+	body, fetched, err := cache.Load("a")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	if string(body) != `{"x": 1}` {
+		t.Errorf("body = %s", body)
+	}
+
+	if time.Since(fetched) > time.Minute {
+		t.Errorf("fetched = %v, want about now", fetched)
+	}
+}
+
+func TestClientOfflineServesScheduledTimes(t *testing.T) {
+	cache := NewResponseCache(t.TempDir())
+	if err := cache.Store(BaseURLKern+"/haltes/2/200144/real-time", []byte(cachedRealtime)); err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClientWithKey("test")
+	client.SetCache(cache)
+	client.SetOffline(true)
+
+	resp, err := client.GetRealtimeByNumber(context.Background(), 200144)
+	if err != nil {
+		t.Fatalf("GetRealtimeByNumber() error: %v", err)
+	}
+
+	d := resp.StopPassages[0].Departures[0]
+	if d.RealTimeRaw != "" || d.IsRealTime() {
+		t.Errorf("cached departure kept its prediction: %+v", d)
+	}
+
+	if d.ScheduledTimeRaw != "2026-10-18T08:00:00" {
+		t.Errorf("scheduled time = %q", d.ScheduledTimeRaw)
+	}
+
+	if _, ok := client.CachedSince(); !ok {
+		t.Error("CachedSince() reports no cached response")
+	}
+
+	if _, err := client.GetRealtimeByNumber(context.Background(), 200145); !errors.Is(err, ErrNotCached) {
+		t.Errorf("uncached stop: err = %v, want ErrNotCached", err)
+	}
+}
+
+func TestClientFallsBackWhenCircuitOpen(t *testing.T) {
+	cache := NewResponseCache(t.TempDir())
+	if err := cache.Store(BaseURLSearch+"/haltes/zoek/korenmarkt", []byte(`{"haltes": [{"haltenummer": 200144}]}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewClientWithKey("test")
+	client.SetCache(cache)
+
+	for range 5 {
+		client.circuitBreaker.RecordFailure()
+	}
+
+	resp, err := client.SearchStops(context.Background(), "korenmarkt")
+	if err != nil {
+		t.Fatalf("SearchStops() error: %v", err)
+	}
+
+	if len(resp.Stops) != 1 || resp.Stops[0].Number != 200144 {
+		t.Errorf("stops = %+v", resp.Stops)
+	}
+
+	var circuitErr *CircuitBreakerError
+	if _, err := client.SearchStops(context.Background(), "gent"); !errors.As(err, &circuitErr) {
+		t.Errorf("uncached query: err = %v, want CircuitBreakerError", err)
+	}
+}
+
+func TestClientRecoversFromCache(t *testing.T) {
+	client := NewClientWithKey("test")
+	client.SetCache(NewResponseCache(t.TempDir()))
+
+	down := false
+	client.httpClient.Transport = roundTripFunc(func(*http.Request) (*http.Response, error) {
+		if down {
+			return nil, errors.New("connection refused")
+		}
+
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(cachedRealtime)), Header: http.Header{}}, nil
+	})
+
+	fetch := func(step string, wantRealtime, wantCached bool) {
+		t.Helper()
+
+		resp, err := client.GetRealtimeByNumber(context.Background(), 200144)
+		if err != nil {
+			t.Fatalf("%s: GetRealtimeByNumber() error: %v", step, err)
+		}
+
+		if got := resp.StopPassages[0].Departures[0].IsRealTime(); got != wantRealtime {
+			t.Errorf("%s: realtime = %v, want %v", step, got, wantRealtime)
+		}
+
+		if _, got := client.CachedSince(); got != wantCached {
+			t.Errorf("%s: CachedSince() = %v, want %v", step, got, wantCached)
+		}
+	}
+
+	fetch("online", true, false)
+
+	down = true
+
+	fetch("offline", false, true)
+
+	down = false
+
+	fetch("online again", true, false)
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/dedene/delijn-cli/internal/auth"
@@ -44,6 +45,27 @@ type Client struct {
 	searchLimiter  *RateLimiter
 	circuitBreaker *CircuitBreaker
 	apiKey         string
+
+	cache    *ResponseCache // nil keeps responses in memory only
+	offline  bool
+	cachedMu sync.Mutex
+	cachedAt map[string]time.Time // responses served from cache, by key
+
+	observe func(Request) // nil when nothing watches requests
+}
+
+// Request describes a request the client sent to the API.
+type Request struct {
+	Product  string        // name of the API product, as in Products
+	Status   int           // HTTP status, or 0 when no response arrived
+	Duration time.Duration // including retries
+}
+
+// Limits is a snapshot of the client's own throttling.
+type Limits struct {
+	KernTokens   int  // requests the core API limiter allows right now
+	SearchTokens int  // same for the search and GTFS-RT APIs
+	CircuitOpen  bool // requests are refused after repeated failures
 }
 
 // NewClient creates a new API client.
@@ -70,8 +92,121 @@ func NewClientWithKey(apiKey string) *Client {
 	}
 }
 
+// SetCache makes the client store successful GET responses in cache and
+// serve them when the network is down or the circuit breaker is open.
+func (c *Client) SetCache(cache *ResponseCache) {
+	c.cache = cache
+}
+
+// SetTransport replaces the client's transport, retries included, e.g. to
+// route requests through a test double.
+func (c *Client) SetTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = rt
+}
+
+// SetOffline makes the client serve every request from its cache without
+// using the network.
+func (c *Client) SetOffline(offline bool) {
+	c.offline = offline
+}
+
+// SetObserver makes the client call observe after every request it sends to
+// the API, e.g. to export metrics. Requests answered from the cache, or
+// refused by the circuit breaker, are not sent.
+func (c *Client) SetObserver(observe func(Request)) {
+	c.observe = observe
+}
+
+// Limits returns the state of the client's rate limiters and circuit
+// breaker.
+func (c *Client) Limits() Limits {
+	return Limits{
+		KernTokens:   c.kernLimiter.Available(),
+		SearchTokens: c.searchLimiter.Available(),
+		CircuitOpen:  c.circuitBreaker.IsOpen(),
+	}
+}
+
+// CachedSince returns when the oldest response served from the cache was
+// fetched, and false when every response came from the API.
+func (c *Client) CachedSince() (time.Time, bool) {
+	c.cachedMu.Lock()
+	defer c.cachedMu.Unlock()
+
+	var oldest time.Time
+
+	for _, t := range c.cachedAt {
+		if oldest.IsZero() || t.Before(oldest) {
+			oldest = t
+		}
+	}
+
+	return oldest, !oldest.IsZero()
+}
+
+func (c *Client) servedFromCache(key string) bool {
+	c.cachedMu.Lock()
+	defer c.cachedMu.Unlock()
+
+	_, ok := c.cachedAt[key]
+
+	return ok
+}
+
+// fromCache decodes the cached response for key into out. It returns err
+// when there is none, so callers can pass the error that sent them here.
+func (c *Client) fromCache(key string, out interface{}, err error) error {
+	if c.cache == nil || out == nil {
+		return err
+	}
+
+	body, fetched, loadErr := c.cache.Load(key)
+	if loadErr != nil {
+		return err
+	}
+
+	if decodeErr := json.Unmarshal(body, out); decodeErr != nil {
+		return err
+	}
+
+	c.cachedMu.Lock()
+	defer c.cachedMu.Unlock()
+
+	if c.cachedAt == nil {
+		c.cachedAt = map[string]time.Time{}
+	}
+
+	c.cachedAt[key] = fetched
+
+	return nil
+}
+
+// servedLive forgets that key was served from the cache once the API
+// answers again.
+func (c *Client) servedLive(key string) {
+	c.cachedMu.Lock()
+	defer c.cachedMu.Unlock()
+
+	delete(c.cachedAt, key)
+}
+
 func (c *Client) do(ctx context.Context, baseURL, method, path string, limiter *RateLimiter, body []byte, out interface{}) error {
+	key := baseURL + path
+	cacheable := method == http.MethodGet
+
+	if c.offline {
+		if !cacheable {
+			return ErrNotCached
+		}
+
+		return c.fromCache(key, out, ErrNotCached)
+	}
+
 	if c.circuitBreaker.IsOpen() {
+		if cacheable {
+			return c.fromCache(key, out, &CircuitBreakerError{})
+		}
+
 		return &CircuitBreakerError{}
 	}
 
@@ -99,11 +234,27 @@ func (c *Client) do(ctx context.Context, baseURL, method, path string, limiter *
 		req.Header.Set("Content-Type", ContentType)
 	}
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
+
+	if c.observe != nil {
+		observed := Request{Product: productName(baseURL), Duration: time.Since(start)}
+		if resp != nil {
+			observed.Status = resp.StatusCode
+		}
+
+		c.observe(observed)
+	}
+
 	if err != nil {
 		c.circuitBreaker.RecordFailure()
 
-		return fmt.Errorf("do request: %w", err)
+		err = fmt.Errorf("do request: %w", err)
+		if cacheable && ctx.Err() == nil {
+			return c.fromCache(key, out, err)
+		}
+
+		return err
 	}
 	defer resp.Body.Close()
 
@@ -148,10 +299,24 @@ func (c *Client) do(ctx context.Context, baseURL, method, path string, limiter *
 
 	c.circuitBreaker.RecordSuccess()
 
-	if out != nil && resp.StatusCode != http.StatusNoContent {
-		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
-			return fmt.Errorf("decode response: %w", err)
-		}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	c.servedLive(key)
+
+	// The cache is a fallback; failing to update it is not an error.
+	if cacheable && c.cache != nil {
+		_ = c.cache.Store(key, respBody)
 	}
 
 	return nil
@@ -213,6 +378,15 @@ func (c *Client) GetRealtime(ctx context.Context, entityNumber, stopNumber int)
 		return nil, err
 	}
 
+	// Cached predictions are out of date; fall back to the timetable.
+	if c.servedFromCache(BaseURLKern + path) {
+		for _, passage := range resp.StopPassages {
+			for i := range passage.Departures {
+				passage.Departures[i].dropPrediction()
+			}
+		}
+	}
+
 	return &resp, nil
 }
 
@@ -223,6 +397,53 @@ func (c *Client) GetRealtimeByNumber(ctx context.Context, stopNumber int) (*Real
 	return c.GetRealtime(ctx, entityNumber, stopNumber)
 }
 
+// StopRealtime is the result for one stop of GetRealtimeForStops.
+type StopRealtime struct {
+	Stop     int
+	Response *RealtimeResponse
+	Err      error
+}
+
+// GetRealtimeForStops retrieves realtime departures for several stops
+// concurrently. The requests still share the Kern rate limiter. Results are
+// in the order of stops.
+func (c *Client) GetRealtimeForStops(ctx context.Context, stops []int) []StopRealtime {
+	results := make([]StopRealtime, len(stops))
+
+	var wg sync.WaitGroup
+
+	for i, stop := range stops {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			resp, err := c.GetRealtimeByNumber(ctx, stop)
+			results[i] = StopRealtime{Stop: stop, Response: resp, Err: err}
+		}()
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// GetStopDisruptions retrieves the disruptions that affect a stop.
+func (c *Client) GetStopDisruptions(ctx context.Context, stopNumber int) ([]Disruption, error) {
+	path := fmt.Sprintf("/haltes/%d/%d/storingen", stopNumber/100000, stopNumber)
+
+	var resp DisruptionsResponse
+	if err := c.GetKern(ctx, path, &resp); err != nil {
+		return nil, err
+	}
+
+	for i := range resp.Disruptions {
+		resp.Disruptions[i].ParseTimes()
+	}
+
+	return resp.Disruptions, nil
+}
+
 // GetLine retrieves a line by entity and line number.
 func (c *Client) GetLine(ctx context.Context, entityNumber, lineNumber int) (*Line, error) {
 	path := fmt.Sprintf("/lijnen/%d/%d", entityNumber, lineNumber)
@@ -259,15 +480,35 @@ func (c *Client) GetLineColours(ctx context.Context, entityNumber, lineNumber in
 	return &colours, nil
 }
 
-// ParseAPITime parses a time string from the API.
-func ParseAPITime(s string) (time.Time, error) {
-	if s == "" {
-		return time.Time{}, nil
+// GetLineDirectionStops retrieves the stops a line direction calls at, in
+// order. direction is HEEN or TERUG.
+func (c *Client) GetLineDirectionStops(ctx context.Context, entityNumber, lineNumber int, direction string) ([]Stop, error) {
+	path := fmt.Sprintf("/lijnen/%d/%d/lijnrichtingen/%s/haltes", entityNumber, lineNumber, url.PathEscape(direction))
+
+	var resp StopsResponse
+	if err := c.GetKern(ctx, path, &resp); err != nil {
+		return nil, err
 	}
 
-	brussels, err := time.LoadLocation("Europe/Brussels")
+	return resp.Stops, nil
+}
+
+// brussels is the timezone of the API's local timestamps.
+var brussels = loadBrussels()
+
+func loadBrussels() *time.Location {
+	loc, err := time.LoadLocation("Europe/Brussels")
 	if err != nil {
-		brussels = time.Local
+		return time.Local
+	}
+
+	return loc
+}
+
+// ParseAPITime parses a time string from the API, in Brussels time.
+func ParseAPITime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
 	}
 
 	t, err := time.ParseInLocation(APITimeFormat, s, brussels)
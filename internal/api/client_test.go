@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestClientObserver(t *testing.T) {
+	c := NewClientWithKey("test")
+	c.httpClient.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if strings.Contains(r.URL.Path, "down") {
+			return nil, errors.New("connection refused")
+		}
+
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{}`)), Header: http.Header{}}, nil
+	})
+
+	var observed []Request
+
+	c.SetObserver(func(r Request) { observed = append(observed, r) })
+
+	ctx := context.Background()
+	_ = c.GetKern(ctx, "/entiteiten", &struct{}{})
+	_ = c.GetSearch(ctx, "/down", &struct{}{})
+
+	if len(observed) != 2 {
+		t.Fatalf("observed %d requests, want 2", len(observed))
+	}
+
+	if r := observed[0]; r.Product != "Kern" || r.Status != http.StatusOK || r.Duration <= 0 {
+		t.Errorf("first request = %+v", r)
+	}
+
+	if r := observed[1]; r.Product != "Zoek" || r.Status != 0 {
+		t.Errorf("failed request = %+v", r)
+	}
+
+	if limits := c.Limits(); limits.KernTokens != 239 || limits.SearchTokens != 5999 || limits.CircuitOpen {
+		t.Errorf("Limits() = %+v", limits)
+	}
+}
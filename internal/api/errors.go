@@ -13,6 +13,7 @@ const (
 	ExitAuth      = 3
 	ExitNotFound  = 4
 	ExitRateLimit = 5
+	ExitCached    = 6 // succeeded with cached data after a network failure
 )
 
 var (
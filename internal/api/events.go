@@ -0,0 +1,213 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// EventType identifies a change between two realtime snapshots.
+type EventType string
+
+const (
+	// EventAdded is a departure that was not in the previous snapshot.
+	EventAdded EventType = "added"
+	// EventDelayChanged is a realtime departure whose delay changed.
+	EventDelayChanged EventType = "delay_changed"
+	// EventRealtime is a departure that switched from scheduled to realtime.
+	EventRealtime EventType = "realtime"
+	// EventDeparted is a departure that left the board after its time.
+	EventDeparted EventType = "departed"
+	// EventRemoved is a departure that left the board before its time.
+	EventRemoved EventType = "removed"
+	// EventCancelled is a departure that was marked as cancelled.
+	EventCancelled EventType = "cancelled"
+	// EventError reports a failed refresh; the watch keeps polling.
+	EventError EventType = "error"
+)
+
+// departedSlack is how long before its expected time a departure that
+// disappears already counts as departed rather than removed: vehicles drop
+// off the board as they arrive, before they leave.
+const departedSlack = 2 * time.Minute
+
+// Event is a change to the departures at a stop.
+type Event struct {
+	Type      EventType  `json:"type"`
+	Time      time.Time  `json:"time"`
+	Stop      int        `json:"stop"`
+	Departure *Departure `json:"departure,omitempty"`
+	Delay     int        `json:"delay_seconds,omitempty"`        // current delay
+	Change    int        `json:"delay_change_seconds,omitempty"` // delay difference for EventDelayChanged
+	Err       error      `json:"-"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// ParseTimes fills ScheduledTime and RealTime from the raw API fields.
+func (d *Departure) ParseTimes() {
+	if t, err := ParseAPITime(d.ScheduledTimeRaw); err == nil {
+		d.ScheduledTime = t
+	}
+
+	d.RealTime = nil
+
+	if d.RealTimeRaw != "" {
+		if t, err := ParseAPITime(d.RealTimeRaw); err == nil {
+			d.RealTime = &t
+		}
+	}
+}
+
+// ExpectedTime returns the realtime prediction, or the scheduled time when
+// there is none.
+func (d *Departure) ExpectedTime() time.Time {
+	if d.RealTime != nil {
+		return *d.RealTime
+	}
+
+	return d.ScheduledTime
+}
+
+// Key identifies a departure across snapshots.
+func (d *Departure) Key() string {
+	return fmt.Sprintf("%d/%d/%s/%s", d.EntityNumber, d.LineNumber, d.Direction, d.ScheduledTimeRaw)
+}
+
+// DiffDepartures compares two snapshots of a stop taken at now and returns
+// the events that turn prev into curr, in the order of curr followed by the
+// departures that disappeared.
+func DiffDepartures(stop int, prev, curr []Departure, now time.Time) []Event {
+	previous := make(map[string]Departure, len(prev))
+	for _, d := range prev {
+		previous[d.Key()] = d
+	}
+
+	var events []Event
+
+	seen := make(map[string]bool, len(curr))
+
+	for _, d := range curr {
+		seen[d.Key()] = true
+
+		event := Event{Time: now, Stop: stop, Departure: &d}
+
+		old, ok := previous[d.Key()]
+		if !ok {
+			event.Type = EventAdded
+			event.Delay = d.DelaySeconds()
+			events = append(events, event)
+
+			continue
+		}
+
+		if d.IsCancelled() && !old.IsCancelled() {
+			event.Type = EventCancelled
+			events = append(events, event)
+
+			continue
+		}
+
+		if d.IsRealTime() && !old.IsRealTime() {
+			event.Type = EventRealtime
+			event.Delay = d.DelaySeconds()
+			events = append(events, event)
+
+			continue
+		}
+
+		if d.IsRealTime() && d.DelaySeconds() != old.DelaySeconds() {
+			event.Type = EventDelayChanged
+			event.Delay = d.DelaySeconds()
+			event.Change = d.DelaySeconds() - old.DelaySeconds()
+			events = append(events, event)
+		}
+	}
+
+	for _, d := range prev {
+		if seen[d.Key()] {
+			continue
+		}
+
+		event := Event{Type: EventRemoved, Time: now, Stop: stop, Departure: &d}
+		if !now.Before(d.ExpectedTime().Add(-departedSlack)) {
+			event.Type = EventDeparted
+		}
+
+		events = append(events, event)
+	}
+
+	return events
+}
+
+// WatchRealtime polls the realtime departures of a stop every interval and
+// sends the changes between successive snapshots. The first snapshot is the
+// baseline and produces no events. Failed refreshes are sent as EventError.
+// The channel is closed when ctx is done.
+func (c *Client) WatchRealtime(ctx context.Context, stop int, interval time.Duration) <-chan Event {
+	events := make(chan Event)
+
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var (
+			prev     []Departure
+			baseline bool
+		)
+
+		for {
+			curr, err := c.realtimeSnapshot(ctx, stop)
+			now := time.Now()
+
+			var batch []Event
+
+			switch {
+			case err != nil:
+				batch = []Event{{Type: EventError, Time: now, Stop: stop, Err: err, Error: err.Error()}}
+			case baseline:
+				batch = DiffDepartures(stop, prev, curr, now)
+				prev = curr
+			default:
+				prev = curr
+				baseline = true
+			}
+
+			for _, event := range batch {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events
+}
+
+func (c *Client) realtimeSnapshot(ctx context.Context, stop int) ([]Departure, error) {
+	resp, err := c.GetRealtimeByNumber(ctx, stop)
+	if err != nil {
+		return nil, fmt.Errorf("get departures: %w", err)
+	}
+
+	var departures []Departure
+
+	for _, passage := range resp.StopPassages {
+		for _, d := range passage.Departures {
+			d.ParseTimes()
+			d.StopNumber = passage.StopNumber
+			departures = append(departures, d)
+		}
+	}
+
+	return departures, nil
+}
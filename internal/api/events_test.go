@@ -0,0 +1,71 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func departure(line int, scheduled, realtime string, statuses ...PredictionStatus) Departure {
+	d := Departure{
+		EntityNumber:     2,
+		LineNumber:       line,
+		Direction:        "HEEN",
+		ScheduledTimeRaw: scheduled,
+		RealTimeRaw:      realtime,
+		PredictionStatus: statuses,
+	}
+	d.ParseTimes()
+
+	return d
+}
+
+func TestDiffDepartures(t *testing.T) {
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, brussels)
+
+	scheduled := departure(1, "2026-03-01T08:10:00", "")
+	realtime := departure(1, "2026-03-01T08:10:00", "2026-03-01T08:11:00", StatusRealtime)
+	later := departure(1, "2026-03-01T08:10:00", "2026-03-01T08:13:00", StatusRealtime)
+	cancelled := departure(1, "2026-03-01T08:10:00", "", StatusCancelled)
+	gone := departure(3, "2026-03-01T07:59:00", "")
+	future := departure(4, "2026-03-01T08:30:00", "")
+	added := departure(5, "2026-03-01T08:20:00", "")
+
+	tests := []struct {
+		name       string
+		prev, curr []Departure
+		want       []EventType
+		wantChange int
+	}{
+		{"unchanged", []Departure{scheduled}, []Departure{scheduled}, nil, 0},
+		{"added", []Departure{scheduled}, []Departure{scheduled, added}, []EventType{EventAdded}, 0},
+		{"to realtime", []Departure{scheduled}, []Departure{realtime}, []EventType{EventRealtime}, 0},
+		{"delay changed", []Departure{realtime}, []Departure{later}, []EventType{EventDelayChanged}, 120},
+		{"cancelled", []Departure{scheduled}, []Departure{cancelled}, []EventType{EventCancelled}, 0},
+		{"departed", []Departure{gone, scheduled}, []Departure{scheduled}, []EventType{EventDeparted}, 0},
+		{"removed early", []Departure{future}, nil, []EventType{EventRemoved}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := DiffDepartures(200552, tt.prev, tt.curr, now)
+
+			if len(events) != len(tt.want) {
+				t.Fatalf("DiffDepartures() = %d events, want %v", len(events), tt.want)
+			}
+
+			for i, event := range events {
+				if event.Type != tt.want[i] {
+					t.Errorf("event %d type = %q, want %q", i, event.Type, tt.want[i])
+				}
+
+				if event.Change != tt.wantChange {
+					t.Errorf("event %d change = %d, want %d", i, event.Change, tt.wantChange)
+				}
+
+				if event.Stop != 200552 || event.Departure == nil {
+					t.Errorf("event %d should carry the stop and departure, got %+v", i, event)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Product is a De Lijn open data API product. Each product needs its own
+// subscription, so a key can be valid for one and rejected by another.
+type Product struct {
+	Name      string
+	BaseURL   string
+	Method    string
+	ProbePath string // cheap request used to test the key
+}
+
+// Products lists the API products used by the CLI.
+var Products = []Product{
+	{Name: "Kern", BaseURL: BaseURLKern, Method: http.MethodGet, ProbePath: "/entiteiten"},
+	{Name: "Zoek", BaseURL: BaseURLSearch, Method: http.MethodGet, ProbePath: "/haltes/zoek/gent?maxAantalHits=1"},
+	{Name: "GTFS-RT", BaseURL: BaseURLGTFS, Method: http.MethodHead, ProbePath: "/realtime"},
+}
+
+// productName returns the name of the product at baseURL.
+func productName(baseURL string) string {
+	for _, p := range Products {
+		if p.BaseURL == baseURL {
+			return p.Name
+		}
+	}
+
+	return baseURL
+}
+
+// ProbeResult is the outcome of a diagnostic request.
+type ProbeResult struct {
+	StatusCode int
+	Date       time.Time // server Date header, zero when absent
+	Latency    time.Duration
+}
+
+// Probe sends a single request for product, bypassing retries, rate limits
+// and the circuit breaker, and discards the body. It is meant for
+// diagnostics, not for fetching data.
+func (c *Client) Probe(ctx context.Context, product Product) (*ProbeResult, error) {
+	req, err := http.NewRequestWithContext(ctx, product.Method, product.BaseURL+product.ProbePath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	if c.apiKey != "" {
+		req.Header.Set(AuthHeader, c.apiKey)
+	}
+
+	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("Accept", ContentType)
+
+	probeClient := &http.Client{Timeout: 10 * time.Second}
+
+	start := time.Now()
+
+	resp, err := probeClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+
+	latency := time.Since(start)
+
+	drainAndClose(resp.Body)
+
+	result := &ProbeResult{StatusCode: resp.StatusCode, Latency: latency}
+
+	if d := resp.Header.Get("Date"); d != "" {
+		if t, err := http.ParseTime(d); err == nil {
+			result.Date = t
+		}
+	}
+
+	return result, nil
+}
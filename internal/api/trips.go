@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrTripNotFound is returned when no trip of a line direction matches.
+var ErrTripNotFound = errors.New("trip not found")
+
+// TripRef identifies a trip: a run (rit) of a line direction today.
+type TripRef struct {
+	Entity    int
+	Line      int
+	Direction string // HEEN or TERUG
+	Number    string // ritnummer
+}
+
+// String formats the reference as a trip ID, e.g. "1-1-HEEN-20345".
+func (r TripRef) String() string {
+	return fmt.Sprintf("%d-%d-%s-%s", r.Entity, r.Line, r.Direction, r.Number)
+}
+
+// ParseTripID parses a trip ID made by TripRef.String.
+func ParseTripID(id string) (TripRef, error) {
+	parts := strings.SplitN(id, "-", 4)
+	if len(parts) != 4 {
+		return TripRef{}, fmt.Errorf("invalid trip ID %q: want ENTITY-LINE-DIRECTION-TRIP", id)
+	}
+
+	entity, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return TripRef{}, fmt.Errorf("invalid trip ID %q: bad entity %q", id, parts[0])
+	}
+
+	line, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return TripRef{}, fmt.Errorf("invalid trip ID %q: bad line %q", id, parts[1])
+	}
+
+	direction := strings.ToUpper(parts[2])
+	if direction != "HEEN" && direction != "TERUG" {
+		return TripRef{}, fmt.Errorf("invalid trip ID %q: direction must be HEEN or TERUG", id)
+	}
+
+	return TripRef{Entity: entity, Line: line, Direction: direction, Number: parts[3]}, nil
+}
+
+// TripRef returns the trip the departure belongs to, and false when De Lijn
+// sent no trip number.
+func (d *Departure) TripRef() (TripRef, bool) {
+	if d.TripNumber == "" {
+		return TripRef{}, false
+	}
+
+	return TripRef{Entity: d.EntityNumber, Line: d.LineNumber, Direction: d.Direction, Number: d.TripNumber.String()}, true
+}
+
+// TripPassage is a call of a trip at a stop. The embedded Departure holds
+// the times and prediction statuses.
+type TripPassage struct {
+	Stop int `json:"haltenummer"`
+	Departure
+}
+
+// Trip is a run of a line direction with its calls in order.
+type Trip struct {
+	Number   json.Number   `json:"ritnummer"`
+	Passages []TripPassage `json:"doorkomsten"`
+}
+
+// TripsResponse is the response from the line direction real-time and
+// timetable endpoints.
+type TripsResponse struct {
+	Trips []Trip `json:"ritDoorkomsten"`
+}
+
+// GetLineDirectionRealtime retrieves today's trips of a line direction with
+// realtime predictions.
+func (c *Client) GetLineDirectionRealtime(ctx context.Context, entityNumber, lineNumber int, direction string) (*TripsResponse, error) {
+	return c.getTrips(ctx, entityNumber, lineNumber, direction, "real-time")
+}
+
+// GetLineDirectionTimetable retrieves today's scheduled trips of a line
+// direction.
+func (c *Client) GetLineDirectionTimetable(ctx context.Context, entityNumber, lineNumber int, direction string) (*TripsResponse, error) {
+	return c.getTrips(ctx, entityNumber, lineNumber, direction, "dienstregelingen")
+}
+
+func (c *Client) getTrips(ctx context.Context, entityNumber, lineNumber int, direction, endpoint string) (*TripsResponse, error) {
+	path := fmt.Sprintf("/lijnen/%d/%d/lijnrichtingen/%s/%s", entityNumber, lineNumber, url.PathEscape(direction), endpoint)
+
+	var resp TripsResponse
+	if err := c.GetKern(ctx, path, &resp); err != nil {
+		return nil, err
+	}
+
+	// Cached predictions are out of date; fall back to the timetable.
+	if endpoint == "real-time" && c.servedFromCache(BaseURLKern+path) {
+		for _, trip := range resp.Trips {
+			for i := range trip.Passages {
+				trip.Passages[i].dropPrediction()
+			}
+		}
+	}
+
+	return &resp, nil
+}
+
+// GetTrip retrieves a trip with parsed times. It prefers realtime data and
+// falls back to the timetable for trips without predictions.
+func (c *Client) GetTrip(ctx context.Context, ref TripRef) (*Trip, error) {
+	realtime, err := c.GetLineDirectionRealtime(ctx, ref.Entity, ref.Line, ref.Direction)
+	if err != nil {
+		return nil, fmt.Errorf("get realtime trips: %w", err)
+	}
+
+	if trip := findTrip(realtime.Trips, ref.Number); trip != nil {
+		return trip, nil
+	}
+
+	timetable, err := c.GetLineDirectionTimetable(ctx, ref.Entity, ref.Line, ref.Direction)
+	if err != nil {
+		return nil, fmt.Errorf("get timetable: %w", err)
+	}
+
+	if trip := findTrip(timetable.Trips, ref.Number); trip != nil {
+		return trip, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrTripNotFound, ref)
+}
+
+func findTrip(trips []Trip, number string) *Trip {
+	for i := range trips {
+		if trips[i].Number.String() != number {
+			continue
+		}
+
+		trip := trips[i]
+		for j := range trip.Passages {
+			trip.Passages[j].ParseTimes()
+			trip.Passages[j].StopNumber = trip.Passages[j].Stop
+		}
+
+		return &trip
+	}
+
+	return nil
+}
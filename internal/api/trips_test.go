@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseTripID(t *testing.T) {
+	ref := TripRef{Entity: 1, Line: 1, Direction: "HEEN", Number: "20345"}
+
+	got, err := ParseTripID(ref.String())
+	if err != nil {
+		t.Fatalf("ParseTripID(%q) error: %v", ref, err)
+	}
+
+	if got != ref {
+		t.Errorf("ParseTripID(%q) = %+v, want %+v", ref, got, ref)
+	}
+
+	for _, id := range []string{"", "1-1-HEEN", "x-1-HEEN-1", "1-x-HEEN-1", "1-1-UP-1"} {
+		if _, err := ParseTripID(id); err == nil {
+			t.Errorf("ParseTripID(%q) should fail", id)
+		}
+	}
+}
+
+func TestDepartureTripRef(t *testing.T) {
+	// De Lijn sends ritnummer as a number or as a string.
+	for _, raw := range []string{
+		`{"entiteitnummer":1,"lijnnummer":5,"richting":"TERUG","ritnummer":812}`,
+		`{"entiteitnummer":1,"lijnnummer":5,"richting":"TERUG","ritnummer":"812"}`,
+	} {
+		var d Departure
+		if err := json.Unmarshal([]byte(raw), &d); err != nil {
+			t.Fatalf("Unmarshal(%s) error: %v", raw, err)
+		}
+
+		ref, ok := d.TripRef()
+		if !ok || ref.String() != "1-5-TERUG-812" {
+			t.Errorf("TripRef() = %v, %v, want 1-5-TERUG-812", ref, ok)
+		}
+	}
+
+	if _, ok := (&Departure{}).TripRef(); ok {
+		t.Error("TripRef() without a trip number should report false")
+	}
+}
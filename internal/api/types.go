@@ -2,6 +2,7 @@
 package api
 
 import (
+	"encoding/json"
 	"slices"
 	"time"
 )
@@ -55,24 +56,79 @@ type LineDirection struct {
 	Destination  string `json:"bestemming"`
 }
 
+// PredictionStatus is a status flag De Lijn attaches to a departure
+// (predictionStatussen). A departure can carry several.
+type PredictionStatus string
+
+const (
+	// StatusRealtime means the departure time is a live prediction.
+	StatusRealtime PredictionStatus = "REALTIME"
+	// StatusCancelled means the trip was cancelled.
+	StatusCancelled PredictionStatus = "GESCHRAPT"
+	// StatusStopDeleted means the trip runs but skips this stop.
+	StatusStopDeleted PredictionStatus = "HALTE_GESCHRAPT"
+	// StatusDiverted means the trip is diverted and may not serve the stop
+	// at its usual place.
+	StatusDiverted PredictionStatus = "OMLEIDING"
+)
+
 // Departure represents a realtime departure at a stop.
 type Departure struct {
-	EntityNumber     int        `json:"entiteitnummer"`
-	LineNumber       int        `json:"lijnnummer"`
-	LinePublicNumber string     `json:"lijnnummerPubliek,omitempty"`
-	Direction        string     `json:"richting"`
-	Destination      string     `json:"bestemming"`
-	ScheduledTime    time.Time  `json:"-"`
-	RealTime         *time.Time `json:"-"`
-	ScheduledTimeRaw string     `json:"dienstregelingTijdstip"`
-	RealTimeRaw      string     `json:"real-timeTijdstip,omitempty"`
-	PredictionStatus []string   `json:"predictionStatussen"`
-	TransportType    string     `json:"vervoertype,omitempty"`
+	EntityNumber     int                `json:"entiteitnummer"`
+	LineNumber       int                `json:"lijnnummer"`
+	LinePublicNumber string             `json:"lijnnummerPubliek,omitempty"`
+	Direction        string             `json:"richting"`
+	Destination      string             `json:"bestemming"`
+	ScheduledTime    time.Time          `json:"-"`
+	RealTime         *time.Time         `json:"-"`
+	ScheduledTimeRaw string             `json:"dienstregelingTijdstip"`
+	RealTimeRaw      string             `json:"real-timeTijdstip,omitempty"`
+	PredictionStatus []PredictionStatus `json:"predictionStatussen"`
+	TransportType    string             `json:"vervoertype,omitempty"`
+	TripNumber       json.Number        `json:"ritnummer,omitempty"`
+	StopNumber       int                `json:"-"` // from the enclosing StopPassage
+}
+
+// HasStatus returns whether the departure carries status.
+func (d *Departure) HasStatus(status PredictionStatus) bool {
+	return slices.Contains(d.PredictionStatus, status)
 }
 
 // IsRealTime returns whether this departure has realtime data.
 func (d *Departure) IsRealTime() bool {
-	return slices.Contains(d.PredictionStatus, "REALTIME")
+	return d.HasStatus(StatusRealtime)
+}
+
+// IsCancelled returns whether this departure was cancelled.
+func (d *Departure) IsCancelled() bool {
+	return d.HasStatus(StatusCancelled)
+}
+
+// SkipsStop returns whether the trip runs but does not call at this stop.
+func (d *Departure) SkipsStop() bool {
+	return d.HasStatus(StatusStopDeleted)
+}
+
+// IsDiverted returns whether the trip is diverted.
+func (d *Departure) IsDiverted() bool {
+	return d.HasStatus(StatusDiverted)
+}
+
+// Status summarises the prediction statuses as one of "cancelled",
+// "stop_skipped", "diverted", "realtime" or "scheduled", most severe first.
+func (d *Departure) Status() string {
+	switch {
+	case d.IsCancelled():
+		return "cancelled"
+	case d.SkipsStop():
+		return "stop_skipped"
+	case d.IsDiverted():
+		return "diverted"
+	case d.IsRealTime():
+		return "realtime"
+	default:
+		return "scheduled"
+	}
 }
 
 // DelaySeconds returns the delay in seconds (positive = late, negative = early).
@@ -84,6 +140,14 @@ func (d *Departure) DelaySeconds() int {
 	return int(d.RealTime.Sub(d.ScheduledTime).Seconds())
 }
 
+// dropPrediction removes the realtime prediction of d, leaving its
+// scheduled time.
+func (d *Departure) dropPrediction() {
+	d.RealTimeRaw = ""
+	d.RealTime = nil
+	d.PredictionStatus = nil
+}
+
 // StopPassage represents passages at a specific stop.
 type StopPassage struct {
 	StopNumber int         `json:"haltenummer"`
@@ -134,6 +198,13 @@ type Disruption struct {
 	Lines        []Line    `json:"lijnen,omitempty"`
 }
 
+// ParseTimes fills StartDate and EndDate from the raw API fields. Dates that
+// do not parse stay zero.
+func (d *Disruption) ParseTimes() {
+	d.StartDate, _ = ParseAPITime(d.StartDateRaw)
+	d.EndDate, _ = ParseAPITime(d.EndDateRaw)
+}
+
 // DisruptionsResponse is the response from disruptions endpoint.
 type DisruptionsResponse struct {
 	Disruptions []Disruption `json:"storingen"`
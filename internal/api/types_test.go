@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDepartureStatus(t *testing.T) {
+	tests := []struct {
+		statuses []PredictionStatus
+		want     string
+	}{
+		{nil, "scheduled"},
+		{[]PredictionStatus{StatusRealtime}, "realtime"},
+		{[]PredictionStatus{StatusRealtime, StatusDiverted}, "diverted"},
+		{[]PredictionStatus{StatusStopDeleted}, "stop_skipped"},
+		{[]PredictionStatus{StatusRealtime, StatusCancelled}, "cancelled"},
+		{[]PredictionStatus{"ONBEKEND"}, "scheduled"},
+	}
+
+	for _, tt := range tests {
+		d := Departure{PredictionStatus: tt.statuses}
+		if got := d.Status(); got != tt.want {
+			t.Errorf("Status(%v) = %q, want %q", tt.statuses, got, tt.want)
+		}
+	}
+}
+
+func TestDepartureUnmarshalStatuses(t *testing.T) {
+	raw := `{"lijnnummer":1,"dienstregelingTijdstip":"2026-03-01T08:10:00","predictionStatussen":["GESCHRAPT"]}`
+
+	var d Departure
+	if err := json.Unmarshal([]byte(raw), &d); err != nil {
+		t.Fatal(err)
+	}
+
+	if !d.IsCancelled() || d.Status() != "cancelled" {
+		t.Errorf("departure with GESCHRAPT should be cancelled, got status %q", d.Status())
+	}
+}
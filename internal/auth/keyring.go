@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
@@ -105,13 +106,16 @@ func isKeychainLockedError(msg string) bool {
 		strings.Contains(msg, "The user name or passphrase you entered is not correct")
 }
 
-func fileKeyringPasswordFunc() keyring.PromptFunc {
+// fileKeyringPasswordFunc returns how the file backend gets its password:
+// from DELIJN_KEYRING_PASSWORD, else from a terminal prompt when prompt is
+// set and stdin is a terminal.
+func fileKeyringPasswordFunc(prompt bool) keyring.PromptFunc {
 	password := os.Getenv(keyringPasswordEnv)
 	if password != "" {
 		return keyring.FixedStringPrompt(password)
 	}
 
-	if term.IsTerminal(int(os.Stdin.Fd())) {
+	if prompt && term.IsTerminal(int(os.Stdin.Fd())) {
 		return keyring.TerminalPrompt
 	}
 
@@ -132,7 +136,7 @@ func shouldUseKeyringTimeout(goos string, backendInfo KeyringBackendInfo, dbusAd
 	return goos == "linux" && backendInfo.Value == keyringBackendAuto && dbusAddr != ""
 }
 
-func openKeyring() (keyring.Keyring, error) {
+func openKeyring(prompt bool) (keyring.Keyring, error) {
 	keyringDir, err := config.EnsureKeyringDir()
 	if err != nil {
 		return nil, fmt.Errorf("ensure keyring dir: %w", err)
@@ -159,7 +163,7 @@ func openKeyring() (keyring.Keyring, error) {
 		KeychainTrustApplication: false,
 		AllowedBackends:          backends,
 		FileDir:                  keyringDir,
-		FilePasswordFunc:         fileKeyringPasswordFunc(),
+		FilePasswordFunc:         fileKeyringPasswordFunc(prompt),
 	}
 
 	if shouldUseKeyringTimeout(runtime.GOOS, backendInfo, dbusAddr) {
@@ -202,7 +206,18 @@ func openKeyringWithTimeout(cfg keyring.Config, timeout time.Duration) (keyring.
 }
 
 func OpenDefault() (Store, error) {
-	ring, err := openKeyringFunc()
+	ring, err := openKeyringFunc(true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyringStore{ring: ring}, nil
+}
+
+// OpenNoPrompt opens the keyring like OpenDefault, but the file backend
+// fails instead of asking for its password, for checks that must not block.
+func OpenNoPrompt() (Store, error) {
+	ring, err := openKeyringFunc(false)
 	if err != nil {
 		return nil, err
 	}
@@ -251,25 +266,33 @@ func (s *KeyringStore) DeleteAPIKey() error {
 	return nil
 }
 
+// HasAPIKey reports whether a key is stored. It lists the stored names
+// without reading them, so the file backend needs no password.
 func (s *KeyringStore) HasAPIKey() (bool, error) {
-	_, err := s.ring.Get(apiKeyKey)
+	keys, err := s.ring.Keys()
 	if err != nil {
-		if errors.Is(err, keyring.ErrKeyNotFound) {
-			return false, nil
-		}
-
-		return false, fmt.Errorf("check API key: %w", err)
+		return false, wrapKeychainError(fmt.Errorf("check API key: %w", err))
 	}
 
-	return true, nil
+	return slices.Contains(keys, apiKeyKey), nil
 }
 
 func GetAPIKey() (string, error) {
+	return getAPIKey(OpenDefault)
+}
+
+// GetAPIKeyNoPrompt is GetAPIKey for checks that must not block: the file
+// backend fails instead of asking for its password.
+func GetAPIKeyNoPrompt() (string, error) {
+	return getAPIKey(OpenNoPrompt)
+}
+
+func getAPIKey(open func() (Store, error)) (string, error) {
 	if envKey := os.Getenv(apiKeyEnv); envKey != "" {
 		return envKey, nil
 	}
 
-	store, err := OpenDefault()
+	store, err := open()
 	if err != nil {
 		return "", err
 	}
@@ -281,3 +304,60 @@ func GetAPIKey() (string, error) {
 
 	return key, nil
 }
+
+// KeyringDiagnostics describes how the keyring will be opened on this machine.
+type KeyringDiagnostics struct {
+	Backend     KeyringBackendInfo
+	Effective   string   // backend that will be tried first
+	ForcedFile  bool     // Linux without a D-Bus session falls back to the file backend
+	DBusAddress string   // DBUS_SESSION_BUS_ADDRESS, empty when unset
+	Available   []string // backends compiled in and usable on this OS
+}
+
+// DiagnoseKeyring reports the keyring backend selection without opening it.
+func DiagnoseKeyring() (KeyringDiagnostics, error) {
+	backendInfo, err := ResolveKeyringBackendInfo()
+	if err != nil {
+		return KeyringDiagnostics{}, err
+	}
+
+	backends, err := allowedBackends(backendInfo)
+	if err != nil {
+		return KeyringDiagnostics{Backend: backendInfo}, err
+	}
+
+	diag := KeyringDiagnostics{
+		Backend:     backendInfo,
+		DBusAddress: os.Getenv("DBUS_SESSION_BUS_ADDRESS"),
+	}
+
+	for _, b := range keyring.AvailableBackends() {
+		diag.Available = append(diag.Available, string(b))
+	}
+
+	diag.ForcedFile = shouldForceFileBackend(runtime.GOOS, backendInfo, diag.DBusAddress)
+
+	switch {
+	case diag.ForcedFile:
+		diag.Effective = string(keyring.FileBackend)
+	case len(backends) > 0:
+		diag.Effective = string(backends[0])
+	case len(diag.Available) > 0:
+		diag.Effective = diag.Available[0]
+	}
+
+	return diag, nil
+}
+
+// IsKeyringLocked reports whether err means the keyring exists but can't be
+// unlocked non-interactively: a locked macOS keychain, a file keyring without
+// password or TTY, or an unresponsive Secret Service.
+func IsKeyringLocked(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return isKeychainLockedError(err.Error()) ||
+		errors.Is(err, errNoTTY) ||
+		errors.Is(err, errKeyringTimeout)
+}
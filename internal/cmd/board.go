@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/output"
+	"github.com/dedene/delijn-cli/internal/schema"
+)
+
+// boardDepartures is the number of departures shown per board row, like the
+// displays at stops.
+const boardDepartures = 3
+
+// groupDepartures collapses departures into board rows keyed by groupBy:
+// "line" (line and destination), "destination" or "direction" (line and
+// direction). Rows are ordered by their first departure and keep at most
+// boardDepartures each; at most limit rows are returned.
+func groupDepartures(departures []api.Departure, groupBy string, limit int) [][]api.Departure {
+	var groups [][]api.Departure
+
+	index := map[string]int{}
+
+	for _, d := range departures {
+		key := groupKey(d, groupBy)
+
+		i, ok := index[key]
+		if !ok {
+			if len(groups) >= limit {
+				continue
+			}
+
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+
+		if len(groups[i]) < boardDepartures {
+			groups[i] = append(groups[i], d)
+		}
+	}
+
+	return groups
+}
+
+func groupKey(d api.Departure, groupBy string) string {
+	line := lineKey(d)
+
+	switch groupBy {
+	case "destination":
+		return strings.ToLower(d.Destination)
+	case "direction":
+		return line + "/" + d.Direction
+	default:
+		return line + "/" + d.Destination
+	}
+}
+
+var boardFields = []output.Field{
+	{Name: "line", Header: "LINE", Table: true, Plain: true},
+	{Name: "destination", Header: "DESTINATION", Table: true, Plain: true},
+	{Name: "next", Header: "NEXT", Table: true},
+	{Name: "times", Header: "TIMES", Plain: true},
+	{Name: "direction", Header: "DIRECTION"},
+	{Name: "type", Header: "TYPE"},
+}
+
+// boardListing renders grouped departures. badges maps entity/line keys to
+// styled line numbers; lines without one are shown plain.
+func boardListing(stops []int, groupBy string, groups [][]api.Departure, badges map[string]string) output.Listing {
+	doc := schema.NewBoard(stops, groupBy, groups)
+	records := make([]output.Record, 0, len(groups))
+
+	for i, g := range groups {
+		var lines, styledLines, next, styledNext, times []string
+
+		for _, d := range g {
+			line := formatLineNumber(d)
+			if !slices.Contains(lines, line) {
+				lines = append(lines, line)
+				styledLines = append(styledLines, lineBadge(d, badges))
+			}
+
+			next = append(next, output.FormatRelative(d.ExpectedTime()))
+			styledNext = append(styledNext, formatBoardTime(d))
+			times = append(times, output.FormatTime(d.ExpectedTime()))
+		}
+
+		records = append(records, output.Record{
+			Item: doc.Groups[i],
+			Values: map[string]string{
+				"line":        strings.Join(lines, ","),
+				"destination": g[0].Destination,
+				"next":        strings.Join(next, ", "),
+				"times":       strings.Join(times, " "),
+				"direction":   g[0].Direction,
+				"type":        g[0].TransportType,
+			},
+			Styled: map[string]string{
+				"line": strings.Join(styledLines, " "),
+				"next": strings.Join(styledNext, ", "),
+			},
+		})
+	}
+
+	return output.Listing{
+		Document: doc,
+		Fields:   boardFields,
+		Records:  records,
+		Empty:    "No departures found.",
+	}
+}
+
+// formatBoardTime shows when d leaves, coloured like its delay. Cancelled
+// trips and trips that skip the stop are struck through.
+func formatBoardTime(d api.Departure) string {
+	in := output.FormatRelative(d.ExpectedTime())
+
+	switch {
+	case d.IsCancelled() || d.SkipsStop():
+		return output.Red(output.Strike(in))
+	case !d.IsRealTime():
+		return output.Dim(in)
+	}
+
+	return output.DelayColor(d.DelaySeconds())(in)
+}
+
+func lineKey(d api.Departure) string {
+	return fmt.Sprintf("%d/%d", d.EntityNumber, d.LineNumber)
+}
+
+func lineBadge(d api.Departure, badges map[string]string) string {
+	if badge, ok := badges[lineKey(d)]; ok {
+		return badge
+	}
+
+	return formatLineNumber(d)
+}
+
+// fetchLineBadges adds the badges of lines in groups that badges lacks, so
+// each line's colours are fetched once. Lines whose colours cannot be
+// fetched render plain.
+func fetchLineBadges(ctx context.Context, client *api.Client, groups [][]api.Departure, badges map[string]string) {
+	for _, g := range groups {
+		for _, d := range g {
+			key := lineKey(d)
+			if _, ok := badges[key]; ok {
+				continue
+			}
+
+			colours, err := client.GetLineColours(ctx, d.EntityNumber, d.LineNumber)
+			if err != nil {
+				badges[key] = formatLineNumber(d)
+
+				continue
+			}
+
+			badges[key] = output.LineBadge(" "+formatLineNumber(d)+" ", colours.Foreground.Hex, colours.Background.Hex)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+)
+
+func boardTestDepartures() []api.Departure {
+	now := time.Now()
+	dep := func(line int, dest, direction string, in time.Duration) api.Departure {
+		return api.Departure{
+			EntityNumber:  1,
+			LineNumber:    line,
+			Destination:   dest,
+			Direction:     direction,
+			ScheduledTime: now.Add(in),
+		}
+	}
+
+	return []api.Departure{
+		dep(1, "Flanders Expo", "HEEN", 1*time.Minute),
+		dep(1, "Flanders Expo", "HEEN", 6*time.Minute),
+		dep(2, "Zwijnaarde", "TERUG", 7*time.Minute),
+		dep(1, "Flanders Expo", "HEEN", 11*time.Minute),
+		dep(1, "Evergem", "HEEN", 12*time.Minute),
+		dep(1, "Flanders Expo", "HEEN", 16*time.Minute),
+		dep(3, "Zwijnaarde", "TERUG", 20*time.Minute),
+	}
+}
+
+func TestGroupDepartures(t *testing.T) {
+	tests := []struct {
+		groupBy string
+		limit   int
+		want    []int // departures per group
+	}{
+		{"line", 10, []int{3, 1, 1, 1}},
+		{"line", 2, []int{3, 1}},
+		{"destination", 10, []int{3, 2, 1}},
+		{"direction", 10, []int{3, 1, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.groupBy, func(t *testing.T) {
+			groups := groupDepartures(boardTestDepartures(), tt.groupBy, tt.limit)
+
+			if len(groups) != len(tt.want) {
+				t.Fatalf("got %d groups, want %d", len(groups), len(tt.want))
+			}
+
+			for i, g := range groups {
+				if len(g) != tt.want[i] {
+					t.Errorf("group %d has %d departures, want %d", i, len(g), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBoardListing(t *testing.T) {
+	groups := groupDepartures(boardTestDepartures(), "destination", 10)
+	l := boardListing([]int{200552}, "destination", groups, nil)
+
+	if len(l.Records) != len(groups) {
+		t.Fatalf("got %d records for %d groups", len(l.Records), len(groups))
+	}
+
+	if got := l.Records[1].Values["line"]; got != "2,3" {
+		t.Errorf("line = %q, want the lines of the group", got)
+	}
+
+	if got := len(strings.Fields(l.Records[0].Values["times"])); got != boardDepartures {
+		t.Errorf("times has %d entries, want %d", got, boardDepartures)
+	}
+}
+
+func TestCSSColor(t *testing.T) {
+	for hex, want := range map[string]string{
+		"FFCC11":  "#FFCC11",
+		"#0a0b0c": "#0a0b0c",
+		"red":     "",
+		"":        "",
+		"#FFF":    "",
+	} {
+		if got := cssColor(hex); got != want {
+			t.Errorf("cssColor(%q) = %q, want %q", hex, got, want)
+		}
+	}
+}
+
+func TestWebBoardFetchFailsWhenAPIUnreachable(t *testing.T) {
+	f := newWebBoardFetcher(unreachableLiveClient(t), []namedStop{{title: "@home", stop: 200144}}, 8)
+
+	doc, err := f.fetch(context.Background())
+	if err == nil {
+		t.Fatalf("fetch() served %+v without the API", doc.Stops)
+	}
+
+	if len(doc.Stops) != 1 || doc.Stops[0].Error == "" || len(doc.Stops[0].Departures) > 0 {
+		t.Errorf("stops = %+v, want the stop's error and no departures", doc.Stops)
+	}
+}
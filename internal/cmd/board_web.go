@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/schema"
+	"github.com/dedene/delijn-cli/internal/web"
+)
+
+type BoardCmd struct {
+	Stops    []string      `arg:"" optional:"" name:"stop" help:"Stops to show (number, name, or @favorite); defaults to all favorites"`
+	HTTP     string        `name:"http" required:"" placeholder:"ADDR" help:"Serve the board as a web page on this address, e.g. :8080"`
+	Interval time.Duration `help:"Refresh interval" default:"30s"`
+	Count    int           `help:"Departures shown per stop" default:"8" short:"n"`
+}
+
+func (c *BoardCmd) Run(root *RootFlags) error {
+	if c.Interval < 10*time.Second {
+		return fmt.Errorf("interval must be at least 10s, got %s", c.Interval)
+	}
+
+	if err := checkCount(c.Count); err != nil {
+		return err
+	}
+
+	// A refresh that fails must show as stale, not as the cached timetable.
+	client, err := root.newLiveClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stops, err := namedStops(ctx, client, c.Stops)
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", c.HTTP)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	runCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	board := web.NewBoard(newWebBoardFetcher(client, stops, c.Count).fetch, c.Interval)
+	go board.Run(runCtx)
+
+	fmt.Fprintf(os.Stderr, "Serving board on http://%s (Ctrl+C to stop)\n", boardURLHost(ln.Addr()))
+
+	srv := &http.Server{Handler: board.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	served := make(chan error, 1)
+
+	go func() {
+		served <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-served:
+		return fmt.Errorf("serve: %w", err)
+	case <-runCtx.Done():
+	}
+
+	// Event streams never finish by themselves, so close them rather than
+	// wait for them.
+	if err := srv.Close(); err != nil {
+		return fmt.Errorf("shut down: %w", err)
+	}
+
+	return nil
+}
+
+// boardURLHost is addr as a host to browse to: a wildcard address, as from
+// --http :8080, becomes localhost.
+func boardURLHost(addr net.Addr) string {
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok || !tcp.IP.IsUnspecified() {
+		return addr.String()
+	}
+
+	return fmt.Sprintf("localhost:%d", tcp.Port)
+}
+
+// webBoardFetcher fetches the departures of the board's stops. Line colours
+// rarely change, so each line's are fetched once.
+type webBoardFetcher struct {
+	client  *api.Client
+	stops   []namedStop
+	fetcher *DeparturesCmd
+	colours map[string]*api.LineColours // by lineKey; nil when unavailable
+}
+
+func newWebBoardFetcher(client *api.Client, stops []namedStop, count int) *webBoardFetcher {
+	return &webBoardFetcher{
+		client:  client,
+		stops:   stops,
+		fetcher: &DeparturesCmd{Count: count},
+		colours: map[string]*api.LineColours{},
+	}
+}
+
+// fetch builds the board document. A stop that fails shows its error; the
+// refresh only fails when every stop does.
+func (f *webBoardFetcher) fetch(ctx context.Context) (web.Document, error) {
+	doc := web.Document{Updated: time.Now(), Stops: make([]web.Stop, 0, len(f.stops))}
+
+	var errs []error
+
+	for _, s := range f.stops {
+		stop := web.Stop{Title: s.title, Stop: s.stop, Departures: []web.Departure{}}
+
+		departures, err := f.fetcher.fetchDepartures(ctx, f.client, []int{s.stop})
+		if err != nil {
+			stop.Error = err.Error()
+			errs = append(errs, err)
+		}
+
+		for _, d := range departures {
+			stop.Departures = append(stop.Departures, f.departure(ctx, d))
+		}
+
+		doc.Stops = append(doc.Stops, stop)
+	}
+
+	if len(errs) == len(f.stops) {
+		return doc, errors.Join(errs...)
+	}
+
+	return doc, nil
+}
+
+func (f *webBoardFetcher) departure(ctx context.Context, d api.Departure) web.Departure {
+	out := web.Departure{Departure: schema.NewDeparture(d)}
+
+	if colours := f.lineColours(ctx, d); colours != nil {
+		out.Foreground = cssColor(colours.Foreground.Hex)
+		out.Background = cssColor(colours.Background.Hex)
+	}
+
+	return out
+}
+
+func (f *webBoardFetcher) lineColours(ctx context.Context, d api.Departure) *api.LineColours {
+	key := lineKey(d)
+	if colours, ok := f.colours[key]; ok {
+		return colours
+	}
+
+	colours, err := f.client.GetLineColours(ctx, d.EntityNumber, d.LineNumber)
+	if err != nil && (api.IsThrottled(err) || errors.Is(err, context.DeadlineExceeded)) {
+		// Try again on a later refresh.
+		return nil
+	}
+
+	f.colours[key] = colours
+
+	return colours
+}
+
+var hexColorPattern = regexp.MustCompile(`^#?[0-9A-Fa-f]{6}$`)
+
+// cssColor returns hex as a CSS colour, or "" when it is not a hex colour.
+func cssColor(hex string) string {
+	if !hexColorPattern.MatchString(hex) {
+		return ""
+	}
+
+	return "#" + strings.TrimPrefix(hex, "#")
+}
@@ -16,7 +16,7 @@ type CompletionBashCmd struct{}
 func (c *CompletionBashCmd) Run() error {
 	script := `_delijn_completions() {
     local cur="${COMP_WORDS[COMP_CWORD]}"
-    local commands="version auth config stops lines departures info completion"
+    local commands="version auth config stops lines departures events trip leave dashboard notify watch-rules serve board exporter info doctor completion"
 
     if [ $COMP_CWORD -eq 1 ]; then
         COMPREPLY=($(compgen -W "$commands" -- "$cur"))
@@ -44,7 +44,17 @@ _delijn() {
         'stops:Search and view stops'
         'lines:Search and view lines'
         'departures:Show realtime departures'
+        'events:Stream departure changes at a stop'
+        'trip:Show the remaining stops of a trip'
+        'leave:Show when to leave for the next departures'
+        'dashboard:Full-screen departures of favorite stops'
+        'notify:Desktop notifications for departures and disruptions'
+        'watch-rules:Run the hooks in config when their rules match'
+        'serve:Serve departures, stops and lines as a local JSON API'
+        'board:Serve a live departure board web page'
+        'exporter:Export departure delays as Prometheus metrics'
         'info:Show CLI and API info'
+        'doctor:Diagnose config, keyring and API connectivity'
         'completion:Generate shell completions'
     )
 
@@ -77,7 +87,17 @@ complete -c delijn -n '__fish_use_subcommand' -a 'config' -d 'Manage configurati
 complete -c delijn -n '__fish_use_subcommand' -a 'stops' -d 'Search and view stops'
 complete -c delijn -n '__fish_use_subcommand' -a 'lines' -d 'Search and view lines'
 complete -c delijn -n '__fish_use_subcommand' -a 'departures' -d 'Show realtime departures'
+complete -c delijn -n '__fish_use_subcommand' -a 'events' -d 'Stream departure changes at a stop'
+complete -c delijn -n '__fish_use_subcommand' -a 'trip' -d 'Show the remaining stops of a trip'
+complete -c delijn -n '__fish_use_subcommand' -a 'leave' -d 'Show when to leave for the next departures'
+complete -c delijn -n '__fish_use_subcommand' -a 'dashboard' -d 'Full-screen departures of favorite stops'
+complete -c delijn -n '__fish_use_subcommand' -a 'notify' -d 'Desktop notifications for departures and disruptions'
+complete -c delijn -n '__fish_use_subcommand' -a 'watch-rules' -d 'Run the hooks in config when their rules match'
+complete -c delijn -n '__fish_use_subcommand' -a 'serve' -d 'Serve departures, stops and lines as a local JSON API'
+complete -c delijn -n '__fish_use_subcommand' -a 'board' -d 'Serve a live departure board web page'
+complete -c delijn -n '__fish_use_subcommand' -a 'exporter' -d 'Export departure delays as Prometheus metrics'
 complete -c delijn -n '__fish_use_subcommand' -a 'info' -d 'Show CLI and API info'
+complete -c delijn -n '__fish_use_subcommand' -a 'doctor' -d 'Diagnose config, keyring and API connectivity'
 complete -c delijn -n '__fish_use_subcommand' -a 'completion' -d 'Generate shell completions'
 `
 	fmt.Fprint(os.Stdout, script)
@@ -1,16 +1,34 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"os/exec"
 	"sort"
 	"strconv"
+	"strings"
 	"text/tabwriter"
+	"time"
 
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dedene/delijn-cli/internal/api"
 	"github.com/dedene/delijn-cli/internal/config"
+	"github.com/dedene/delijn-cli/internal/output"
+	"github.com/dedene/delijn-cli/internal/schema"
 )
 
 type ConfigCmd struct {
+	Get            ConfigGetCmd            `cmd:"" help:"Print a config value"`
+	Set            ConfigSetCmd            `cmd:"" help:"Set a config value"`
+	Unset          ConfigUnsetCmd          `cmd:"" help:"Remove a config value"`
+	Edit           ConfigEditCmd           `cmd:"" help:"Open config.yaml in $EDITOR"`
+	Show           ConfigShowCmd           `cmd:"" help:"Show the effective configuration"`
 	SetFavorite    ConfigSetFavoriteCmd    `cmd:"" name:"set-favorite" help:"Set a favorite stop alias"`
 	RemoveFavorite ConfigRemoveFavoriteCmd `cmd:"" name:"remove-favorite" help:"Remove a favorite stop alias"`
 	ListFavorites  ConfigListFavoritesCmd  `cmd:"" name:"list-favorites" help:"List all favorite stops"`
@@ -59,34 +77,417 @@ func (c *ConfigListFavoritesCmd) Run(root *RootFlags) error {
 		return fmt.Errorf("list favorites: %w", err)
 	}
 
-	if len(favorites) == 0 {
-		fmt.Fprintln(os.Stdout, "No favorites configured.")
-		fmt.Fprintln(os.Stdout, "")
-		fmt.Fprintln(os.Stdout, "Add a favorite with:")
-		fmt.Fprintln(os.Stdout, "  delijn config set-favorite <name> <stop-number>")
+	return root.render(favoritesListing(favorites))
+}
 
-		return nil
+// favoritesDocument is the favorites document of `config list-favorites`
+// and `serve`, sorted by alias.
+func favoritesDocument(favorites map[string]config.Favorite) schema.Favorites {
+	doc := schema.Favorites{SchemaVersion: schema.Version, Favorites: make([]schema.Favorite, 0, len(favorites))}
+
+	for alias, fav := range favorites {
+		doc.Favorites = append(doc.Favorites, schema.Favorite{
+			Alias:       alias,
+			Stop:        fav.Stop,
+			Name:        fav.Name,
+			WalkSeconds: int(fav.WalkTime().Seconds()),
+		})
+	}
+
+	sort.Slice(doc.Favorites, func(i, j int) bool { return doc.Favorites[i].Alias < doc.Favorites[j].Alias })
+
+	return doc
+}
+
+var favoriteFields = []output.Field{
+	{Name: "name", Header: "NAME", Table: true, Plain: true},
+	{Name: "stop", Header: "STOP", Table: true, Plain: true},
+	{Name: "description", Header: "DESCRIPTION", Table: true, Plain: true},
+	{Name: "walk", Header: "WALK", Table: true},
+}
+
+func favoritesListing(favorites map[string]config.Favorite) output.Listing {
+	doc := favoritesDocument(favorites)
+	records := make([]output.Record, 0, len(doc.Favorites))
+
+	for _, fav := range doc.Favorites {
+		records = append(records, output.Record{
+			Item: fav,
+			Values: map[string]string{
+				"name":        "@" + fav.Alias,
+				"stop":        strconv.Itoa(fav.Stop),
+				"description": fav.Name,
+				"walk":        favorites[fav.Alias].Walk,
+			},
+		})
+	}
+
+	return output.Listing{
+		Document: doc,
+		Fields:   favoriteFields,
+		Records:  records,
+		Empty: "No favorites configured.\n\nAdd a favorite with:\n" +
+			"  delijn config set-favorite <name> <stop-number>",
+	}
+}
+
+type ConfigGetCmd struct {
+	Key string `arg:"" required:"" help:"Config key (e.g., timezone, favorites.home)"`
+}
+
+func (c *ConfigGetCmd) Run() error {
+	key, err := config.LookupKey(c.Key)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.ReadConfig()
+	if err != nil {
+		return err
+	}
+
+	value, ok := key.Get(cfg)
+	if !ok {
+		return &ExitError{Code: 1, Err: fmt.Errorf("%s is not set", key.Name)}
+	}
+
+	fmt.Fprintln(os.Stdout, value)
+
+	return nil
+}
+
+type ConfigSetCmd struct {
+	Key   string `arg:"" required:"" help:"Config key (e.g., timezone, favorites.home)"`
+	Value string `arg:"" required:"" help:"New value"`
+}
+
+func (c *ConfigSetCmd) Run() error {
+	key, err := config.LookupKey(c.Key)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.ReadUserConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := key.Set(&cfg, c.Value); err != nil {
+		return err
+	}
+
+	if err := verifyStopKeys(cfg, key.Name); err != nil {
+		return err
+	}
+
+	if err := config.WriteConfig(cfg); err != nil {
+		return err
+	}
+
+	value, _ := key.Get(cfg)
+	fmt.Fprintf(os.Stdout, "%s = %s\n", key.Name, value)
+
+	return nil
+}
+
+type ConfigUnsetCmd struct {
+	Key string `arg:"" required:"" help:"Config key to remove"`
+}
+
+func (c *ConfigUnsetCmd) Run() error {
+	key, err := config.LookupKey(c.Key)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.ReadUserConfig()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := key.Get(cfg); !ok {
+		return fmt.Errorf("%s is not set", key.Name)
+	}
+
+	key.Unset(&cfg)
+
+	if err := config.WriteConfig(cfg); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stdout, "%s unset\n", key.Name)
+
+	return nil
+}
+
+type ConfigEditCmd struct{}
+
+func (c *ConfigEditCmd) Run() error {
+	path, err := config.ConfigPath()
+	if err != nil {
+		return err
+	}
+
+	original, err := os.ReadFile(path) //nolint:gosec // config file path
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read config: %w", err)
+	}
+
+	tmp, err := os.CreateTemp("", "delijn-config-*.yaml")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(original); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("write temp file: %w", err)
+	}
+
+	tmp.Close()
+
+	for {
+		if err := runEditor(tmp.Name()); err != nil {
+			return err
+		}
+
+		edited, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			return fmt.Errorf("read edited config: %w", err)
+		}
+
+		validateErr := validateEditedConfig(edited)
+		if validateErr == nil {
+			if string(edited) == string(original) {
+				fmt.Fprintln(os.Stdout, "No changes.")
+
+				return nil
+			}
+
+			// Keep the user's comments, key order and unknown keys.
+			if err := config.WriteConfigBytes(edited); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(os.Stdout, "Saved %s\n", path)
+
+			return nil
+		}
+
+		fmt.Fprintf(os.Stderr, "Invalid config:\n%v\n", validateErr)
+
+		if !confirm("Edit again?") {
+			return fmt.Errorf("config not saved: %w", validateErr)
+		}
+	}
+}
+
+func validateEditedConfig(b []byte) error {
+	cfg, warnings, err := config.ParseConfig(b)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	return verifyStopKeys(cfg, "")
+}
+
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	if editor == "" {
+		editor = "vi"
+	}
+
+	parts := strings.Fields(editor)
+	cmd := exec.Command(parts[0], append(parts[1:], path)...) //nolint:gosec // user-chosen editor
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run editor %q: %w", editor, err)
+	}
+
+	return nil
+}
+
+func confirm(prompt string) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+
+	fmt.Fprintf(os.Stderr, "%s [Y/n] ", prompt)
+
+	reader := bufio.NewReader(os.Stdin)
+	answer, _ := reader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "" || answer == "y" || answer == "yes"
+}
+
+type ConfigShowCmd struct {
+	Origin bool `help:"Show which config file each value comes from"`
+}
+
+func (c *ConfigShowCmd) Run(root *RootFlags) error {
+	if c.Origin {
+		return c.showOrigins(root)
+	}
+
+	cfg, err := config.ReadConfig()
+	if err != nil {
+		return err
+	}
+
+	if root.JSON {
+		return outputJSON(cfg)
+	}
+
+	b, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("encode config yaml: %w", err)
+	}
+
+	fmt.Fprint(os.Stdout, string(b))
+
+	return nil
+}
+
+type configOrigin struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Origin string `json:"origin"`
+	Path   string `json:"path,omitempty"`
+}
+
+func (c *ConfigShowCmd) showOrigins(root *RootFlags) error {
+	layers, err := config.ReadLayers()
+	if err != nil {
+		return err
+	}
+
+	cfg, origins := config.Merge(layers)
+	rows := make([]configOrigin, 0, len(origins))
+
+	for _, name := range config.SortedOriginKeys(origins) {
+		key, err := config.LookupKey(name)
+		if err != nil {
+			continue
+		}
+
+		value, _ := key.Get(cfg)
+		layer := origins[name]
+		rows = append(rows, configOrigin{Key: name, Value: value, Origin: layer.Name, Path: layer.Path})
 	}
 
 	if root.JSON {
-		return outputJSON(favorites)
+		return outputJSON(rows)
 	}
 
-	// Sort by name for consistent output
-	names := make([]string, 0, len(favorites))
-	for name := range favorites {
-		names = append(names, name)
+	if root.Plain {
+		for _, r := range rows {
+			fmt.Fprintf(os.Stdout, "%s\t%s\t%s\t%s\n", r.Key, r.Value, r.Origin, r.Path)
+		}
+
+		return nil
 	}
 
-	sort.Strings(names)
+	if len(rows) == 0 {
+		fmt.Fprintln(os.Stdout, "No configuration values set.")
+
+		return nil
+	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	defer w.Flush()
 
-	fmt.Fprintln(w, "NAME\tSTOP")
+	fmt.Fprintln(w, "KEY\tVALUE\tORIGIN\tPATH")
+
+	for _, r := range rows {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Key, r.Value, r.Origin, r.Path)
+	}
+
+	return nil
+}
+
+// favoriteDefined reports whether alias is a favorite in cfg or in any
+// other config layer.
+func favoriteDefined(cfg config.File, alias string) bool {
+	if _, ok := cfg.Favorites[alias]; ok {
+		return true
+	}
+
+	effective, err := config.ReadConfig()
+	if err != nil {
+		return false
+	}
+
+	_, ok := effective.Favorites[alias]
+
+	return ok
+}
+
+// verifyStopKeys checks that stop numbers referenced by the config exist.
+// When only is set, just that key is checked. Lookups that fail for reasons
+// other than "not found" (no API key, offline) are reported as warnings.
+func verifyStopKeys(cfg config.File, only string) error {
+	var stops []int
+
+	if only == "" || only == "default_stop" {
+		if alias, ok := strings.CutPrefix(cfg.DefaultStop, "@"); ok {
+			if !favoriteDefined(cfg, alias) {
+				return fmt.Errorf("default_stop: favorite %q: %w", alias, config.ErrFavoriteNotFound)
+			}
+		} else if n, err := strconv.Atoi(cfg.DefaultStop); err == nil {
+			stops = append(stops, n)
+		}
+	}
+
+	for name, fav := range cfg.Favorites {
+		if only == "" || only == "favorites."+name || only == "favorites."+name+".stop" {
+			stops = append(stops, fav.Stop)
+		}
+	}
+
+	if len(stops) == 0 {
+		return nil
+	}
+
+	client, err := api.NewClient()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot verify stop numbers: %v\n", err)
+
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, stopNum := range stops {
+		_, err := client.GetStopByNumber(ctx, stopNum)
+
+		var apiErr *api.APIError
 
-	for _, name := range names {
-		fmt.Fprintf(w, "@%s\t%d\n", name, favorites[name])
+		switch {
+		case err == nil:
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+			return fmt.Errorf("stop %d does not exist", stopNum)
+		default:
+			fmt.Fprintf(os.Stderr, "Warning: cannot verify stop %d: %v\n", stopNum, err)
+		}
 	}
 
 	return nil
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"syscall"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/config"
+	"github.com/dedene/delijn-cli/internal/output"
+	"github.com/dedene/delijn-cli/internal/tui"
+)
+
+type DashboardCmd struct {
+	Stops    []string      `arg:"" optional:"" name:"stop" help:"Stops to show (number, name, or @favorite); defaults to all favorites"`
+	Interval time.Duration `help:"Refresh interval" default:"30s"`
+	Count    int           `help:"Departures fetched per stop" default:"10" short:"n"`
+}
+
+func (c *DashboardCmd) Run(root *RootFlags) error {
+	output.SetNoColor(root.NoColor)
+
+	if c.Interval < 10*time.Second {
+		return fmt.Errorf("interval must be at least 10s, got %s", c.Interval)
+	}
+
+	if err := checkCount(c.Count); err != nil {
+		return err
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return errors.New("dashboard needs an interactive terminal; use 'departures --watch' in scripts")
+	}
+
+	client, err := root.newClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	panels, err := c.panels(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	runCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	t, err := tui.Open(os.Stdin, os.Stdout, true)
+	if err != nil {
+		return err
+	}
+
+	runErr := tui.NewDashboard(panels, c.Interval).Run(runCtx, t)
+
+	if err := t.Close(); err != nil && runErr == nil {
+		runErr = err
+	}
+
+	return runErr
+}
+
+// panels builds one panel per stop argument, or per favorite when there are
+// none. Each panel fetches like `delijn departures <stop>`.
+func (c *DashboardCmd) panels(ctx context.Context, client *api.Client) ([]*tui.Panel, error) {
+	stops, err := namedStops(ctx, client, c.Stops)
+	if err != nil {
+		return nil, err
+	}
+
+	fetcher := &DeparturesCmd{Count: c.Count}
+	panels := make([]*tui.Panel, 0, len(stops))
+
+	for _, s := range stops {
+		panels = append(panels, &tui.Panel{
+			Title: s.title,
+			Fetch: func(ctx context.Context) ([]api.Departure, error) {
+				fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+				defer cancel()
+
+				return fetcher.fetchDepartures(fetchCtx, client, []int{s.stop})
+			},
+		})
+	}
+
+	return panels, nil
+}
+
+// namedStop is a resolved stop with a title to show it by.
+type namedStop struct {
+	title string
+	stop  int
+}
+
+// namedStops resolves refs, or every favorite when refs is empty, sorted by
+// name.
+func namedStops(ctx context.Context, client *api.Client, refs []string) ([]namedStop, error) {
+	var stops []namedStop
+
+	if len(refs) > 0 {
+		for _, ref := range refs {
+			stop, err := ResolveStop(ctx, client, ref)
+			if err != nil {
+				return nil, err
+			}
+
+			stops = append(stops, namedStop{title: fmt.Sprintf("%s (%d)", ref, stop), stop: stop})
+		}
+
+		return stops, nil
+	}
+
+	favorites, err := config.ListFavorites()
+	if err != nil {
+		return nil, fmt.Errorf("list favorites: %w", err)
+	}
+
+	if len(favorites) == 0 {
+		return nil, errors.New("no favorites to show; pass stops or add one with 'delijn config set-favorite <name> <stop>'")
+	}
+
+	names := make([]string, 0, len(favorites))
+	for name := range favorites {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		fav := favorites[name]
+
+		title := "@" + name
+		if fav.Name != "" {
+			title += " " + fav.Name
+		}
+
+		stops = append(stops, namedStop{title: title + " (" + strconv.Itoa(fav.Stop) + ")", stop: fav.Stop})
+	}
+
+	return stops, nil
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alecthomas/kong"
+
+	"github.com/dedene/delijn-cli/internal/config"
+)
+
+const envPrefix = "DELIJN_"
+
+// outputKey is a shorthand default that selects the output format.
+const outputKey = "output"
+
+// flagDefaultsResolver supplies flag values that weren't given on the command
+// line, from DELIJN_<COMMAND>_<FLAG> environment variables first and then from
+// the defaults section of the merged config. Flags with their own env tag
+// (e.g. NO_COLOR) keep it.
+type flagDefaultsResolver struct {
+	values map[string]string
+}
+
+func newFlagDefaultsResolver(cfg config.File) *flagDefaultsResolver {
+	values := make(map[string]string)
+	for k, v := range cfg.DefaultValues() {
+		values[strings.ReplaceAll(k, "_", "-")] = v
+	}
+
+	return &flagDefaultsResolver{values: values}
+}
+
+func (r *flagDefaultsResolver) Validate(app *kong.Application) error {
+	known := map[string]bool{outputKey: true}
+
+	_ = kong.Visit(app.Node, func(n kong.Visitable, next kong.Next) error {
+		if node, ok := n.(*kong.Node); ok {
+			for _, flag := range node.Flags {
+				known[flagConfigKey(node, flag)] = true
+			}
+		}
+
+		return next(nil)
+	})
+
+	for _, key := range config.SortedDefaultKeys(r.values) {
+		if !known[key] {
+			fmt.Fprintf(os.Stderr, "Warning: config defaults.%s does not match any flag\n", key)
+		}
+	}
+
+	return nil
+}
+
+func (r *flagDefaultsResolver) Resolve(kctx *kong.Context, parent *kong.Path, flag *kong.Flag) (any, error) {
+	switch flag.Name {
+	case "help", "version", "config", "profile":
+		return nil, nil
+	}
+
+	for _, env := range flag.Tag.Envs {
+		if _, ok := os.LookupEnv(env); ok {
+			return nil, nil
+		}
+	}
+
+	key := flagConfigKey(parent.Node(), flag)
+
+	if v, ok := os.LookupEnv(flagEnvName(key)); ok {
+		return v, nil
+	}
+
+	if v, ok := r.values[key]; ok {
+		return v, nil
+	}
+
+	if parent.Node().Parent == nil {
+		return r.resolveOutput(kctx, flag.Name), nil
+	}
+
+	return nil, nil
+}
+
+// resolveOutput maps the output shorthand (DELIJN_OUTPUT or defaults.output)
+// onto the root --format flag, and onto --json or --plain for those values,
+// unless an output flag was given explicitly.
+func (r *flagDefaultsResolver) resolveOutput(kctx *kong.Context, flagName string) any {
+	for _, p := range kctx.Path {
+		if p.Flag != nil && !p.Resolved {
+			switch p.Flag.Name {
+			case "json", "plain", "format", "template":
+				return nil
+			}
+		}
+	}
+
+	mode, ok := os.LookupEnv(flagEnvName(outputKey))
+	if !ok {
+		mode, ok = r.values[outputKey]
+	}
+
+	if !ok {
+		return nil
+	}
+
+	mode = strings.ToLower(strings.TrimSpace(mode))
+
+	switch flagName {
+	case "format":
+		return mode
+	case mode:
+		return "true"
+	}
+
+	return nil
+}
+
+// flagConfigKey returns the dotted config key for a flag, e.g.
+// "departures.count" or "no-color" for root flags.
+func flagConfigKey(node *kong.Node, flag *kong.Flag) string {
+	var parts []string
+
+	for n := node; n != nil; n = n.Parent {
+		if n.Type == kong.CommandNode {
+			parts = append([]string{n.Name}, parts...)
+		}
+	}
+
+	return strings.Join(append(parts, flag.Name), ".")
+}
+
+// flagEnvName returns the environment variable for a config key, e.g.
+// DELIJN_DEPARTURES_COUNT.
+func flagEnvName(key string) string {
+	return envPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
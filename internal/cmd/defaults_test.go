@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func parseWithConfig(t *testing.T, configYAML string, args ...string) *CLI {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+
+	if err := os.WriteFile(path, []byte(configYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(dir)
+	t.Setenv("DELIJN_CONFIG", path)
+
+	parser, err := newParser()
+	if err != nil {
+		t.Fatalf("newParser() error: %v", err)
+	}
+
+	if _, err := parser.Parse(args); err != nil {
+		t.Fatalf("Parse(%v) error: %v", args, err)
+	}
+
+	cli, ok := parser.Model.Target.Addr().Interface().(*CLI)
+	if !ok {
+		t.Fatal("parser target is not *CLI")
+	}
+
+	return cli
+}
+
+func TestFlagDefaultsFromConfig(t *testing.T) {
+	cfg := "defaults:\n  output: json\n  departures:\n    count: 5\n    line: \"1\"\n"
+
+	cli := parseWithConfig(t, cfg, "departures", "200552")
+
+	if cli.Departures.Count != 5 {
+		t.Errorf("Count = %d, want 5 from config", cli.Departures.Count)
+	}
+
+	if cli.Departures.Line != "1" {
+		t.Errorf("Line = %q, want \"1\" from config", cli.Departures.Line)
+	}
+
+	if !cli.JSON {
+		t.Error("output: json should enable --json")
+	}
+}
+
+func TestFlagDefaultsPrecedence(t *testing.T) {
+	cfg := "defaults:\n  output: json\n  departures.count: 5\n"
+
+	t.Run("flag beats config", func(t *testing.T) {
+		cli := parseWithConfig(t, cfg, "departures", "200552", "--count", "3", "--plain")
+
+		if cli.Departures.Count != 3 {
+			t.Errorf("Count = %d, want 3 from flag", cli.Departures.Count)
+		}
+
+		if cli.JSON {
+			t.Error("explicit --plain should suppress the output default")
+		}
+	})
+
+	t.Run("env beats config", func(t *testing.T) {
+		t.Setenv("DELIJN_DEPARTURES_COUNT", "7")
+
+		cli := parseWithConfig(t, cfg, "departures", "200552")
+
+		if cli.Departures.Count != 7 {
+			t.Errorf("Count = %d, want 7 from DELIJN_DEPARTURES_COUNT", cli.Departures.Count)
+		}
+	})
+
+	t.Run("kong default without config", func(t *testing.T) {
+		cli := parseWithConfig(t, "", "departures", "200552")
+
+		if cli.Departures.Count != 10 {
+			t.Errorf("Count = %d, want kong default 10", cli.Departures.Count)
+		}
+	})
+}
+
+func TestOutputFormatFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     string
+		args       []string
+		wantFormat string
+	}{
+		{"default", "", nil, "table"},
+		{"json shorthand", "", []string{"--json"}, "json"},
+		{"plain shorthand", "", []string{"--plain"}, "plain"},
+		{"format flag", "", []string{"--format", "csv"}, "csv"},
+		{"template implies format", "", []string{"--template", "{{.Line}}"}, "template"},
+		{"output default", "defaults:\n  output: yaml\n", nil, "yaml"},
+		{"format beats output default", "defaults:\n  output: json\n", []string{"--format", "csv"}, "csv"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"departures", "200552"}, tt.args...)
+			cli := parseWithConfig(t, tt.config, args...)
+
+			if cli.Format != tt.wantFormat {
+				t.Errorf("Format = %q, want %q", cli.Format, tt.wantFormat)
+			}
+
+			if cli.JSON != (tt.wantFormat == "json") || cli.Plain != (tt.wantFormat == "plain") {
+				t.Errorf("JSON = %v, Plain = %v, inconsistent with format %q", cli.JSON, cli.Plain, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestFlagEnvName(t *testing.T) {
+	tests := map[string]string{
+		"departures.count": "DELIJN_DEPARTURES_COUNT",
+		"no-color":         "DELIJN_NO_COLOR",
+		"output":           "DELIJN_OUTPUT",
+	}
+
+	for key, want := range tests {
+		if got := flagEnvName(key); got != want {
+			t.Errorf("flagEnvName(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -5,26 +5,53 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"slices"
+	"sort"
 	"strconv"
 	"syscall"
-	"text/tabwriter"
 	"time"
 
+	"golang.org/x/term"
+
 	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/filter"
 	"github.com/dedene/delijn-cli/internal/output"
+	"github.com/dedene/delijn-cli/internal/schema"
 )
 
 type DeparturesCmd struct {
-	Stop  string `arg:"" required:"" help:"Stop (number, name, or @favorite)"`
-	Watch bool   `help:"Auto-refresh every 30 seconds" short:"w"`
-	Count int    `help:"Maximum number of departures" default:"10" short:"n"`
-	Line  string `help:"Filter by line number" short:"l"`
+	Stops         []string `arg:"" name:"stop" required:"" help:"Stops (number, name, or @favorite); departures of several stops are merged"`
+	Area          bool     `help:"Expand each stop to every stop of its station, e.g. all perrons of Gent Sint-Pieters"`
+	Watch         bool     `help:"Auto-refresh every 30 seconds" short:"w"`
+	ChangesOnly   bool     `help:"With --watch, only emit snapshots that differ from the previous one (NDJSON output)"`
+	Count         int      `help:"Maximum number of departures" default:"10" short:"n"`
+	Line          string   `help:"Filter by line number" short:"l"`
+	HideCancelled bool     `help:"Leave out cancelled trips and trips that skip this stop"`
+	Where         string   `help:"Filter expression, e.g. 'line in (\"1\",\"2\") && delay > 120'"`
+	GroupBy       string   `help:"Show one row per line, destination or direction with the next three departures; --count limits rows" enum:",line,destination,direction" default:""`
+	To            string   `help:"Only departures whose trip later calls at this stop (number, name, or @favorite)"`
+	WithTripIDs   bool     `help:"Add a TRIP column with IDs for 'delijn trip'"`
+
+	where  *filter.Filter
+	route  *routeFilter
+	badges map[string]string // line badges by entity/line, kept across refreshes
 }
 
 func (c *DeparturesCmd) Run(root *RootFlags) error {
 	output.SetNoColor(root.NoColor)
 
-	client, err := api.NewClient()
+	if err := checkCount(c.Count); err != nil {
+		return err
+	}
+
+	where, err := compileWhere(c.Where, departureFilterFields)
+	if err != nil {
+		return err
+	}
+
+	c.where = where
+
+	client, err := root.newClient()
 	if err != nil {
 		return err
 	}
@@ -32,31 +59,81 @@ func (c *DeparturesCmd) Run(root *RootFlags) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	stopNumber, err := ResolveStop(ctx, client, c.Stop)
+	stops, err := c.resolveStops(ctx, client)
 	if err != nil {
 		return err
 	}
 
+	if c.To != "" {
+		to, err := ResolveStop(ctx, client, c.To)
+		if err != nil {
+			return fmt.Errorf("--to: %w", err)
+		}
+
+		c.route = newRouteFilter(to)
+	}
+
 	if c.Watch {
-		return c.runWatch(client, stopNumber, root)
+		return c.runWatch(client, stops, root)
+	}
+
+	return c.runOnce(client, stops, root)
+}
+
+// resolveStops resolves the stop arguments, expanding them to whole stations
+// with --area. Stops named more than once are fetched once.
+func (c *DeparturesCmd) resolveStops(ctx context.Context, client *api.Client) ([]int, error) {
+	var stops []int
+
+	for _, ref := range c.Stops {
+		var numbers []int
+
+		if c.Area {
+			area, err := ResolveArea(ctx, client, ref)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, s := range area {
+				numbers = append(numbers, s.Number)
+			}
+		} else {
+			stop, err := ResolveStop(ctx, client, ref)
+			if err != nil {
+				return nil, err
+			}
+
+			numbers = []int{stop}
+		}
+
+		for _, n := range numbers {
+			if !slices.Contains(stops, n) {
+				stops = append(stops, n)
+			}
+		}
 	}
 
-	return c.runOnce(client, stopNumber, root)
+	return stops, nil
 }
 
-func (c *DeparturesCmd) runOnce(client *api.Client, stopNumber int, root *RootFlags) error {
+func (c *DeparturesCmd) runOnce(client *api.Client, stops []int, root *RootFlags) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	departures, err := c.fetchDepartures(ctx, client, stopNumber)
+	departures, err := c.fetchDepartures(ctx, client, stops)
 	if err != nil {
 		return err
 	}
 
-	return c.output(departures, root)
+	return c.output(ctx, client, stops, departures, root)
 }
 
-func (c *DeparturesCmd) runWatch(client *api.Client, stopNumber int, root *RootFlags) error {
+func (c *DeparturesCmd) runWatch(client *api.Client, stops []int, root *RootFlags) error {
+	// A table on an interactive terminal is redrawn in place and takes keys.
+	if root.Format == output.FormatTable && term.IsTerminal(int(os.Stdout.Fd())) && term.IsTerminal(int(os.Stdin.Fd())) {
+		return c.runLiveWatch(client, stops, root, watchInterval)
+	}
+
 	// Handle Ctrl+C gracefully
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -64,152 +141,317 @@ func (c *DeparturesCmd) runWatch(client *api.Client, stopNumber int, root *RootF
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
-	ticker := time.NewTicker(30 * time.Second)
+	ticker := time.NewTicker(watchInterval)
 	defer ticker.Stop()
 
-	// First fetch
-	if err := c.fetchAndPrint(ctx, client, stopNumber, root); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-	}
+	// JSON, or a piped table, gets one NDJSON snapshot per refresh instead of
+	// screen redraws, so it can be consumed line by line. Other formats are
+	// rendered once per refresh.
+	tty := term.IsTerminal(int(os.Stdout.Fd()))
+	streaming := root.Format == output.FormatJSON || root.Format == output.FormatNDJSON ||
+		(root.Format == output.FormatTable && !tty)
+	stream := newSnapshotStream(os.Stdout, c.ChangesOnly)
+
+	var (
+		cache    watchCache
+		backoff  = api.NewBackoff(watchInterval)
+		next     time.Time
+		fetchErr error
+	)
+
+	for first := true; ; first = false {
+		now := time.Now()
+
+		// While backing off, ticks between fetches still redraw a table so
+		// its countdowns follow the clock. Ticks can land just before next,
+		// hence the slack.
+		fetch := !now.Add(watchInterval / 2).Before(next)
+		if fetch {
+			fetchErr = c.refreshCache(ctx, client, stops, &cache)
+			next = now.Add(backoff.Next(fetchErr))
+		}
+
+		switch {
+		case streaming:
+			if fetch {
+				if err := stream.Emit(c.snapshot(stops, &cache, fetchErr, now)); err != nil {
+					return err
+				}
+			}
+		case fetch || root.Format == output.FormatTable:
+			if !first && root.Format == output.FormatTable {
+				// Clear screen and move cursor to top
+				fmt.Fprint(os.Stdout, "\033[2J\033[H")
+			}
+
+			if err := c.printCache(ctx, client, stops, &cache, fetchErr, next, root); err != nil {
+				return err
+			}
+		}
 
-	for {
 		select {
 		case <-sigCh:
-			fmt.Fprintln(os.Stdout)
+			if !streaming {
+				fmt.Fprintln(os.Stdout)
+			}
 
 			return nil
 		case <-ticker.C:
-			// Clear screen and move cursor to top
-			fmt.Fprint(os.Stdout, "\033[2J\033[H")
-
-			if err := c.fetchAndPrint(ctx, client, stopNumber, root); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			}
 		}
 	}
 }
 
-func (c *DeparturesCmd) fetchAndPrint(ctx context.Context, client *api.Client, stopNumber int, root *RootFlags) error {
+// refreshCache fetches the departures of stops into cache. On failure the
+// cache keeps the last successful refresh.
+func (c *DeparturesCmd) refreshCache(ctx context.Context, client *api.Client, stops []int, cache *watchCache) error {
 	fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	departures, err := c.fetchDepartures(fetchCtx, client, stopNumber)
+	departures, err := c.collectDepartures(fetchCtx, client, stops)
 	if err != nil {
 		return err
 	}
 
-	return c.output(departures, root)
+	cache.departures = departures
+	cache.updated = time.Now()
+
+	return nil
+}
+
+// snapshot is the cached departures as a watch snapshot. When the refresh
+// failed with err, they are marked stale.
+func (c *DeparturesCmd) snapshot(stops []int, cache *watchCache, err error, now time.Time) schema.Snapshot {
+	snap := schema.Snapshot{
+		SchemaVersion: schema.Version,
+		Timestamp:     now,
+		Stop:          stops[0],
+		Stops:         stops,
+		Departures:    schema.NewDepartureList(c.limit(cache.current(now))),
+	}
+
+	if err != nil {
+		snap.Error = err.Error()
+
+		if updated := cache.updated; !updated.IsZero() {
+			snap.StaleSince = &updated
+		}
+	}
+
+	return snap
+}
+
+// printCache renders the cached departures. When the refresh failed with err,
+// the error and a stale marker follow on stderr; until a refresh succeeds
+// only the error is shown.
+func (c *DeparturesCmd) printCache(ctx context.Context, client *api.Client, stops []int, cache *watchCache, err error, next time.Time, root *RootFlags) error {
+	if !cache.updated.IsZero() {
+		renderCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		defer cancel()
+
+		if err := c.output(renderCtx, client, stops, c.limit(cache.current(time.Now())), root); err != nil {
+			return err
+		}
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+
+		if !cache.updated.IsZero() {
+			fmt.Fprintf(os.Stderr, "Stale since %s; next refresh in %s\n",
+				output.FormatTimeWithSeconds(cache.updated), time.Until(next).Round(time.Second))
+		}
+	}
+
+	return nil
 }
 
-func (c *DeparturesCmd) fetchDepartures(ctx context.Context, client *api.Client, stopNumber int) ([]api.Departure, error) {
-	resp, err := client.GetRealtimeByNumber(ctx, stopNumber)
+// fetchDepartures fetches the departures of all stops, merged by time and
+// limited to --count.
+func (c *DeparturesCmd) fetchDepartures(ctx context.Context, client *api.Client, stops []int) ([]api.Departure, error) {
+	departures, err := c.collectDepartures(ctx, client, stops)
 	if err != nil {
-		return nil, fmt.Errorf("get departures: %w", err)
+		return nil, err
+	}
+
+	return c.limit(departures), nil
+}
+
+// limit caps departures at --count. A board limits its rows instead, so it
+// keeps every departure.
+func (c *DeparturesCmd) limit(departures []api.Departure) []api.Departure {
+	if c.GroupBy == "" && len(departures) > c.Count {
+		return departures[:max(c.Count, 0)]
 	}
 
-	var departures []api.Departure
+	return departures
+}
+
+// checkCount rejects a --count below 1, which may also come from the
+// environment or a config default.
+func checkCount(count int) error {
+	if count < 1 {
+		return &ExitError{Code: 2, Err: fmt.Errorf("--count must be at least 1, got %d", count)}
+	}
+
+	return nil
+}
 
-	for _, passage := range resp.StopPassages {
-		for _, dep := range passage.Departures {
-			// Parse times
-			scheduled, err := output.ParseAPITime(dep.ScheduledTimeRaw)
-			if err == nil {
-				dep.ScheduledTime = scheduled
+// collectDepartures fetches the departures of all stops concurrently and
+// merges them by time. Stops that fail are reported as warnings as long as
+// one succeeds.
+func (c *DeparturesCmd) collectDepartures(ctx context.Context, client *api.Client, stops []int) ([]api.Departure, error) {
+	var (
+		departures []api.Departure
+		firstErr   error
+		failed     int
+	)
+
+	now := time.Now()
+
+	for _, result := range client.GetRealtimeForStops(ctx, stops) {
+		if result.Err != nil {
+			if firstErr == nil {
+				firstErr = result.Err
 			}
 
-			if dep.RealTimeRaw != "" {
-				realtime, err := output.ParseAPITime(dep.RealTimeRaw)
-				if err == nil {
-					dep.RealTime = &realtime
-				}
+			failed++
+
+			if len(stops) > 1 {
+				fmt.Fprintf(os.Stderr, "Warning: get departures for stop %d: %v\n", result.Stop, result.Err)
 			}
 
-			// Filter by line if specified
-			if c.Line != "" {
-				lineNum, _ := strconv.Atoi(c.Line)
-				if dep.LineNumber != lineNum && dep.LinePublicNumber != c.Line {
+			continue
+		}
+
+		for _, passage := range result.Response.StopPassages {
+			for _, dep := range passage.Departures {
+				dep.ParseTimes()
+				dep.StopNumber = passage.StopNumber
+
+				// Filter by line if specified
+				if c.Line != "" && !matchesLine(dep, c.Line) {
 					continue
 				}
-			}
 
-			departures = append(departures, dep)
+				if c.HideCancelled && (dep.IsCancelled() || dep.SkipsStop()) {
+					continue
+				}
+
+				if !c.where.Match(departureFilterRecord(dep, now)) {
+					continue
+				}
+
+				reaches, err := c.route.reaches(ctx, client, dep)
+				if err != nil {
+					return nil, err
+				}
+
+				if !reaches {
+					continue
+				}
 
-			if len(departures) >= c.Count {
-				break
+				departures = append(departures, dep)
 			}
 		}
+	}
 
-		if len(departures) >= c.Count {
-			break
-		}
+	if failed == len(stops) {
+		return nil, fmt.Errorf("get departures: %w", firstErr)
 	}
 
+	// Each stop's departures are in time order already; the stable sort
+	// keeps that order for equal times.
+	sort.SliceStable(departures, func(i, j int) bool {
+		return departures[i].ExpectedTime().Before(departures[j].ExpectedTime())
+	})
+
 	return departures, nil
 }
 
-func (c *DeparturesCmd) output(departures []api.Departure, root *RootFlags) error {
-	if root.JSON {
-		return outputJSON(departures)
-	}
-
-	if root.Plain {
-		outputDeparturesPlain(departures)
+func (c *DeparturesCmd) output(ctx context.Context, client *api.Client, stops []int, departures []api.Departure, root *RootFlags) error {
+	return root.render(c.listing(ctx, client, stops, departures, root))
+}
 
-		return nil
+// listing shows departures as a list or, with --group-by, as a board.
+func (c *DeparturesCmd) listing(ctx context.Context, client *api.Client, stops []int, departures []api.Departure, root *RootFlags) output.Listing {
+	if c.GroupBy == "" {
+		return departuresListing(stops, departures, c.WithTripIDs)
 	}
 
-	outputDeparturesTable(departures)
-
-	return nil
-}
+	groups := groupDepartures(departures, c.GroupBy, c.Count)
 
-func outputDeparturesTable(departures []api.Departure) {
-	if len(departures) == 0 {
-		fmt.Fprintln(os.Stdout, "No departures found.")
+	if root.Format == output.FormatTable && output.ColorEnabled() {
+		if c.badges == nil {
+			c.badges = map[string]string{}
+		}
 
-		return
+		fetchLineBadges(ctx, client, groups, c.badges)
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	defer w.Flush()
+	return boardListing(stops, c.GroupBy, groups, c.badges)
+}
+
+var departureFields = []output.Field{
+	{Name: "time", Header: "TIME", Table: true, Plain: true},
+	{Name: "in", Header: "IN", Table: true},
+	{Name: "stop", Header: "STOP"}, // shown by default for several stops
+	{Name: "line", Header: "LINE", Table: true, Plain: true},
+	{Name: "destination", Header: "DESTINATION", Table: true, Plain: true},
+	{Name: "delay", Header: "DELAY", Table: true, Plain: true},
+	{Name: "status", Header: "STATUS", Plain: true},
+	{Name: "scheduled", Header: "SCHEDULED"},
+	{Name: "direction", Header: "DIRECTION"},
+	{Name: "type", Header: "TYPE"},
+	{Name: "trip", Header: "TRIP"}, // shown by default with --with-trip-ids
+}
 
-	fmt.Fprintln(w, "TIME\tIN\tLINE\tDESTINATION\tDELAY")
+func departuresListing(stops []int, departures []api.Departure, withTripIDs bool) output.Listing {
+	doc := schema.NewDepartures(stops, departures)
+	records := make([]output.Record, 0, len(departures))
 
-	for _, d := range departures {
-		displayTime := d.ScheduledTime
-		if d.RealTime != nil {
-			displayTime = *d.RealTime
+	fields := slices.Clone(departureFields)
+	for i := range fields {
+		if (fields[i].Name == "stop" && len(stops) > 1) || (fields[i].Name == "trip" && withTripIDs) {
+			fields[i].Table, fields[i].Plain = true, true
 		}
+	}
 
-		timeStr := output.FormatTime(displayTime)
-		relStr := output.FormatRelative(displayTime)
-		lineStr := formatLineNumber(d)
-		delayStr := formatDelayStr(d)
+	for i, d := range departures {
+		displayTime := d.ExpectedTime()
+
+		records = append(records, output.Record{
+			Item: doc.Departures[i],
+			Values: map[string]string{
+				"time":        output.FormatTime(displayTime),
+				"in":          output.FormatRelative(displayTime),
+				"stop":        strconv.Itoa(d.StopNumber),
+				"line":        formatLineNumber(d),
+				"destination": d.Destination,
+				"delay":       strconv.Itoa(d.DelaySeconds()),
+				"status":      d.Status(),
+				"scheduled":   output.FormatTime(d.ScheduledTime),
+				"direction":   d.Direction,
+				"type":        d.TransportType,
+				"trip":        doc.Departures[i].TripID,
+			},
+			Styled: map[string]string{"delay": formatDelayStr(d)},
+		})
+	}
 
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
-			timeStr,
-			relStr,
-			lineStr,
-			d.Destination,
-			delayStr,
-		)
+	return output.Listing{
+		Document: doc,
+		Fields:   fields,
+		Records:  records,
+		Empty:    "No departures found.",
 	}
 }
 
-func outputDeparturesPlain(departures []api.Departure) {
-	for _, d := range departures {
-		displayTime := d.ScheduledTime
-		if d.RealTime != nil {
-			displayTime = *d.RealTime
-		}
+// matchesLine reports whether d belongs to line, given as internal or public
+// line number.
+func matchesLine(d api.Departure, line string) bool {
+	lineNum, _ := strconv.Atoi(line)
 
-		fmt.Fprintf(os.Stdout, "%s\t%s\t%s\t%d\n",
-			output.FormatTime(displayTime),
-			formatLineNumber(d),
-			d.Destination,
-			d.DelaySeconds(),
-		)
-	}
+	return d.LineNumber == lineNum || d.LinePublicNumber == line
 }
 
 func formatLineNumber(d api.Departure) string {
@@ -221,6 +463,15 @@ func formatLineNumber(d api.Departure) string {
 }
 
 func formatDelayStr(d api.Departure) string {
+	switch {
+	case d.IsCancelled():
+		return output.Red("CANCELLED")
+	case d.SkipsStop():
+		return output.Red("STOP SKIPPED")
+	case d.IsDiverted():
+		return output.Yellow("DIVERTED")
+	}
+
 	if !d.IsRealTime() {
 		return output.Dim("scheduled")
 	}
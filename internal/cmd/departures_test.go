@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dedene/delijn-cli/internal/api"
+)
+
+func TestDeparturesLimit(t *testing.T) {
+	departures := make([]api.Departure, 3)
+
+	for _, tt := range []struct {
+		count, want int
+	}{
+		{2, 2},
+		{5, 3},
+		{0, 0},
+		{-1, 0},
+	} {
+		c := &DeparturesCmd{Count: tt.count}
+		if got := len(c.limit(departures)); got != tt.want {
+			t.Errorf("limit() with --count %d kept %d, want %d", tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestCheckCount(t *testing.T) {
+	if err := checkCount(1); err != nil {
+		t.Errorf("checkCount(1) = %v", err)
+	}
+
+	var exitErr *ExitError
+	if err := checkCount(-1); !errors.As(err, &exitErr) || exitErr.Code != 2 {
+		t.Errorf("checkCount(-1) = %v, want a usage error", err)
+	}
+}
@@ -0,0 +1,482 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/auth"
+	"github.com/dedene/delijn-cli/internal/config"
+	"github.com/dedene/delijn-cli/internal/output"
+)
+
+var errDoctorFailed = errors.New("doctor found problems")
+
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+	checkSkip = "skip"
+)
+
+// DoctorCmd skips its network checks with the global --offline.
+type DoctorCmd struct{}
+
+type doctorCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+func (c *DoctorCmd) Run(root *RootFlags) error {
+	output.SetNoColor(root.NoColor)
+
+	var checks []doctorCheck
+
+	checks = append(checks, checkConfigDir())
+	checks = append(checks, checkConfigFiles()...)
+	checks = append(checks, checkKeyringBackend()...)
+	checks = append(checks, checkStoredKey())
+
+	if root.Offline {
+		checks = append(checks, doctorCheck{Name: "network", Status: checkSkip, Message: "skipped (--offline)"})
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		checks = append(checks, checkNetwork(ctx)...)
+	}
+
+	if root.JSON {
+		if err := outputJSON(checks); err != nil {
+			return err
+		}
+	} else {
+		printDoctorChecks(checks, root.Plain)
+	}
+
+	failed := 0
+
+	for _, ch := range checks {
+		if ch.Status == checkFail {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return &ExitError{Code: 1, Err: fmt.Errorf("%w: %d check(s) failed", errDoctorFailed, failed)}
+	}
+
+	return nil
+}
+
+func printDoctorChecks(checks []doctorCheck, plain bool) {
+	for _, ch := range checks {
+		if plain {
+			fmt.Fprintf(os.Stdout, "%s\t%s\t%s\t%s\n", ch.Status, ch.Name, ch.Message, ch.Hint)
+
+			continue
+		}
+
+		fmt.Fprintf(os.Stdout, "%s  %-16s %s\n", formatCheckStatus(ch.Status), ch.Name, ch.Message)
+
+		if ch.Hint != "" && ch.Status != checkPass {
+			for _, line := range strings.Split(ch.Hint, "\n") {
+				fmt.Fprintf(os.Stdout, "      %-16s %s\n", "", output.Dim(line))
+			}
+		}
+	}
+}
+
+func formatCheckStatus(status string) string {
+	label := strings.ToUpper(status)
+
+	switch status {
+	case checkPass:
+		return output.Green(label)
+	case checkWarn:
+		return output.Yellow(label)
+	case checkFail:
+		return output.Red(label)
+	default:
+		return output.Dim(label)
+	}
+}
+
+func checkConfigDir() doctorCheck {
+	ch := doctorCheck{Name: "config dir"}
+
+	dir, err := config.Dir()
+	if err != nil {
+		ch.Status, ch.Message = checkFail, err.Error()
+		ch.Hint = "Set XDG_CONFIG_HOME or HOME so the config directory can be located."
+
+		return ch
+	}
+
+	info, err := os.Stat(dir)
+
+	switch {
+	case os.IsNotExist(err):
+		ch.Status, ch.Message = checkPass, dir+" (not created yet)"
+	case err != nil:
+		ch.Status, ch.Message = checkFail, err.Error()
+	case !info.IsDir():
+		ch.Status, ch.Message = checkFail, dir+" is not a directory"
+		ch.Hint = "Move the file out of the way; delijn recreates the directory."
+	case runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0:
+		ch.Status = checkWarn
+		ch.Message = fmt.Sprintf("%s is accessible by other users (%04o)", dir, info.Mode().Perm())
+		ch.Hint = "chmod 700 " + dir
+	default:
+		ch.Status, ch.Message = checkPass, fmt.Sprintf("%s (%04o)", dir, info.Mode().Perm())
+	}
+
+	return ch
+}
+
+func checkConfigFiles() []doctorCheck {
+	var checks []doctorCheck
+
+	userPath, err := config.ConfigPath()
+	if err != nil {
+		return []doctorCheck{{Name: "config file", Status: checkFail, Message: err.Error()}}
+	}
+
+	checks = append(checks, checkConfigFile("config file", userPath))
+
+	sharedPath, err := config.SharedConfigPath()
+	if err == nil && sharedPath != "" {
+		checks = append(checks, checkConfigFile("shared config", sharedPath))
+	}
+
+	return checks
+}
+
+func checkConfigFile(name, path string) doctorCheck {
+	ch := doctorCheck{Name: name}
+
+	b, err := os.ReadFile(path) //nolint:gosec // config file path
+	if err != nil {
+		if os.IsNotExist(err) {
+			ch.Status, ch.Message = checkPass, path+" (not created yet)"
+
+			return ch
+		}
+
+		ch.Status, ch.Message = checkFail, err.Error()
+
+		return ch
+	}
+
+	cfg, warnings, err := config.ParseConfig(b)
+	if err != nil {
+		ch.Status, ch.Message = checkFail, fmt.Sprintf("%s: %v", path, err)
+		ch.Hint = "Fix the file with 'delijn config edit', or restore " + config.BackupPath(path)
+
+		return ch
+	}
+
+	if err := cfg.Validate(); err != nil {
+		warnings = append(warnings, strings.Split(err.Error(), "\n")...)
+	}
+
+	if len(warnings) > 0 {
+		ch.Status, ch.Message = checkWarn, path
+		ch.Hint = strings.Join(warnings, "\n")
+
+		return ch
+	}
+
+	ch.Status, ch.Message = checkPass, path
+
+	return ch
+}
+
+func checkKeyringBackend() []doctorCheck {
+	backend := doctorCheck{Name: "keyring backend"}
+
+	diag, err := auth.DiagnoseKeyring()
+	if err != nil {
+		backend.Status, backend.Message = checkFail, err.Error()
+		backend.Hint = "Run 'delijn config set keyring_backend auto' or unset DELIJN_KEYRING_BACKEND."
+
+		return []doctorCheck{backend}
+	}
+
+	backend.Status = checkPass
+	backend.Message = fmt.Sprintf("%s (configured: %s, source: %s)", diag.Effective, diag.Backend.Value, diag.Backend.Source)
+
+	if runtime.GOOS != "linux" {
+		return []doctorCheck{backend}
+	}
+
+	return []doctorCheck{backend, checkDBus(diag)}
+}
+
+func checkDBus(diag auth.KeyringDiagnostics) doctorCheck {
+	ch := doctorCheck{Name: "d-bus session"}
+
+	if diag.DBusAddress == "" {
+		ch.Status = checkWarn
+		ch.Message = "DBUS_SESSION_BUS_ADDRESS is not set"
+
+		if diag.ForcedFile {
+			ch.Message += "; using the encrypted file keyring"
+		}
+
+		ch.Hint = "Set DELIJN_KEYRING_PASSWORD so the file keyring opens without a prompt,\n" +
+			"or start a session bus (e.g. 'dbus-run-session -- $SHELL') to use Secret Service."
+
+		return ch
+	}
+
+	if path, ok := strings.CutPrefix(diag.DBusAddress, "unix:path="); ok {
+		path, _, _ = strings.Cut(path, ",")
+		if _, err := os.Stat(path); err != nil {
+			ch.Status = checkFail
+			ch.Message = fmt.Sprintf("session bus socket %s is missing", path)
+			ch.Hint = "Your DBUS_SESSION_BUS_ADDRESS is stale (common over SSH or in tmux).\n" +
+				"Unset it or set DELIJN_KEYRING_BACKEND=file."
+
+			return ch
+		}
+	}
+
+	ch.Status, ch.Message = checkPass, diag.DBusAddress
+
+	return ch
+}
+
+func checkStoredKey() doctorCheck {
+	ch := doctorCheck{Name: "api key"}
+
+	envKey := os.Getenv("DELIJN_API_KEY") != ""
+
+	// Doctor must never block on the file backend's password prompt.
+	store, err := auth.OpenNoPrompt()
+	if err == nil {
+		var hasKey bool
+
+		hasKey, err = store.HasAPIKey()
+		if err == nil {
+			switch {
+			case hasKey && envKey:
+				ch.Status, ch.Message = checkPass, "stored in keyring (overridden by DELIJN_API_KEY)"
+			case hasKey:
+				ch.Status, ch.Message = checkPass, "stored in keyring"
+			case envKey:
+				ch.Status, ch.Message = checkPass, "from DELIJN_API_KEY"
+			default:
+				ch.Status, ch.Message = checkFail, "not configured"
+				ch.Hint = "Run 'delijn auth set-key'. Get a key from https://data.delijn.be/"
+			}
+
+			return ch
+		}
+	}
+
+	if envKey {
+		ch.Status = checkWarn
+		ch.Message = "using DELIJN_API_KEY; keyring unavailable: " + err.Error()
+
+		return ch
+	}
+
+	ch.Status, ch.Message = checkFail, err.Error()
+
+	if auth.IsKeyringLocked(err) {
+		ch.Message = "keyring is locked: " + err.Error()
+		ch.Hint = "Unlock the keyring, set DELIJN_KEYRING_PASSWORD for the file backend,\n" +
+			"or export DELIJN_API_KEY instead."
+	}
+
+	return ch
+}
+
+func checkNetwork(ctx context.Context) []doctorCheck {
+	var checks []doctorCheck
+
+	hosts := make(map[string][]string)
+
+	var order []string
+
+	for _, p := range api.Products {
+		u, err := url.Parse(p.BaseURL)
+		if err != nil {
+			continue
+		}
+
+		if _, seen := hosts[u.Host]; !seen {
+			order = append(order, u.Host)
+		}
+
+		hosts[u.Host] = append(hosts[u.Host], p.Name)
+	}
+
+	reachable := true
+
+	for _, host := range order {
+		dns, tlsCheck := checkHost(ctx, host, hosts[host])
+		checks = append(checks, dns, tlsCheck)
+
+		if dns.Status == checkFail || tlsCheck.Status == checkFail {
+			reachable = false
+		}
+	}
+
+	if !reachable {
+		return append(checks, doctorCheck{Name: "api products", Status: checkSkip, Message: "skipped (API unreachable)"})
+	}
+
+	productChecks, serverDate, latency := checkProducts(ctx)
+	checks = append(checks, productChecks...)
+
+	return append(checks, checkClockSkew(serverDate, latency))
+}
+
+func checkHost(ctx context.Context, host string, products []string) (doctorCheck, doctorCheck) {
+	label := fmt.Sprintf("%s (%s)", host, strings.Join(products, ", "))
+	dns := doctorCheck{Name: "dns"}
+	tlsCheck := doctorCheck{Name: "tls"}
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		dns.Status, dns.Message = checkFail, fmt.Sprintf("%s: %v", label, err)
+		dns.Hint = "Check your network connection and DNS resolver."
+		tlsCheck.Status, tlsCheck.Message = checkSkip, "skipped (DNS failed)"
+
+		return dns, tlsCheck
+	}
+
+	dns.Status, dns.Message = checkPass, fmt.Sprintf("%s -> %s", label, strings.Join(addrs, ", "))
+
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: 10 * time.Second},
+		Config:    &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12},
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, "443"))
+	if err != nil {
+		tlsCheck.Status, tlsCheck.Message = checkFail, fmt.Sprintf("%s: %v", host, err)
+		tlsCheck.Hint = "A proxy or firewall may block HTTPS, or intercept it with an untrusted certificate."
+
+		return dns, tlsCheck
+	}
+
+	defer conn.Close()
+
+	tlsCheck.Status, tlsCheck.Message = checkPass, host+":443"
+
+	if tc, ok := conn.(*tls.Conn); ok {
+		state := tc.ConnectionState()
+		if len(state.PeerCertificates) > 0 {
+			expires := state.PeerCertificates[0].NotAfter
+			tlsCheck.Message = fmt.Sprintf("%s:443 (%s, certificate valid until %s)",
+				host, tls.VersionName(state.Version), expires.Format(time.DateOnly))
+		}
+	}
+
+	return dns, tlsCheck
+}
+
+func checkProducts(ctx context.Context) ([]doctorCheck, time.Time, time.Duration) {
+	// Like checkStoredKey, never ask for the keyring password.
+	key, keyErr := auth.GetAPIKeyNoPrompt()
+	client := api.NewClientWithKey(key)
+
+	var (
+		checks     []doctorCheck
+		serverDate time.Time
+		latency    time.Duration
+	)
+
+	for _, p := range api.Products {
+		ch := doctorCheck{Name: "key " + p.Name}
+
+		res, err := client.Probe(ctx, p)
+		if err != nil {
+			ch.Status, ch.Message = checkFail, fmt.Sprintf("%s: %v", p.BaseURL, err)
+			checks = append(checks, ch)
+
+			continue
+		}
+
+		if serverDate.IsZero() && !res.Date.IsZero() {
+			serverDate, latency = res.Date, res.Latency
+		}
+
+		switch {
+		case auth.IsKeyringLocked(keyErr):
+			ch.Status, ch.Message = checkSkip, "keyring is locked; key not tested"
+		case keyErr != nil:
+			ch.Status, ch.Message = checkSkip, "no API key to test"
+		case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+			ch.Status = checkFail
+			ch.Message = fmt.Sprintf("key rejected (HTTP %d)", res.StatusCode)
+			ch.Hint = fmt.Sprintf("Subscribe your key to the %s product at https://data.delijn.be/,\n"+
+				"then run 'delijn auth set-key' if you received a new key.", p.Name)
+		case res.StatusCode == http.StatusTooManyRequests:
+			ch.Status, ch.Message = checkWarn, "key accepted but rate limited (HTTP 429)"
+		case res.StatusCode >= 500:
+			ch.Status = checkWarn
+			ch.Message = fmt.Sprintf("API error (HTTP %d) in %s", res.StatusCode, res.Latency.Round(time.Millisecond))
+		default:
+			ch.Status = checkPass
+			ch.Message = fmt.Sprintf("key accepted (HTTP %d) in %s", res.StatusCode, res.Latency.Round(time.Millisecond))
+		}
+
+		checks = append(checks, ch)
+	}
+
+	return checks, serverDate, latency
+}
+
+func checkClockSkew(serverDate time.Time, latency time.Duration) doctorCheck {
+	ch := doctorCheck{Name: "clock"}
+
+	if serverDate.IsZero() {
+		ch.Status, ch.Message = checkSkip, "no Date header received"
+
+		return ch
+	}
+
+	// The Date header has one-second resolution and was generated roughly
+	// halfway through the round trip.
+	skew := time.Since(serverDate.Add(latency / 2)).Round(time.Second)
+
+	abs := skew
+	if abs < 0 {
+		abs = -abs
+	}
+
+	direction := "ahead of"
+	if skew < 0 {
+		direction = "behind"
+	}
+
+	ch.Message = fmt.Sprintf("local clock is %s %s the API", abs, direction)
+
+	switch {
+	case abs <= 30*time.Second:
+		ch.Status = checkPass
+	case abs <= 2*time.Minute:
+		ch.Status = checkWarn
+		ch.Hint = "Departure countdowns will be off; enable NTP time sync."
+	default:
+		ch.Status = checkFail
+		ch.Hint = "Departure countdowns will be wrong; enable NTP time sync (e.g. 'timedatectl set-ntp true')."
+	}
+
+	return ch
+}
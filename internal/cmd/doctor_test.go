@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/auth"
+)
+
+func TestCheckClockSkew(t *testing.T) {
+	tests := []struct {
+		name   string
+		date   time.Time
+		status string
+	}{
+		{"no date", time.Time{}, checkSkip},
+		{"in sync", time.Now(), checkPass},
+		{"slightly off", time.Now().Add(-time.Minute), checkWarn},
+		{"far behind", time.Now().Add(10 * time.Minute), checkFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ch := checkClockSkew(tt.date, 0); ch.Status != tt.status {
+				t.Errorf("status = %s, want %s (%s)", ch.Status, tt.status, ch.Message)
+			}
+		})
+	}
+}
+
+func TestCheckConfigDir(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(dir string) error
+		status string
+	}{
+		{"missing", func(string) error { return nil }, checkPass},
+		{"private", func(dir string) error { return os.Mkdir(dir, 0o700) }, checkPass},
+		{"shared", func(dir string) error {
+			if err := os.Mkdir(dir, 0o700); err != nil {
+				return err
+			}
+
+			return os.Chmod(dir, 0o755)
+		}, checkWarn},
+		{"file", func(dir string) error { return os.WriteFile(dir, nil, 0o600) }, checkFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			xdg := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", xdg)
+
+			if err := tt.setup(filepath.Join(xdg, "delijn")); err != nil {
+				t.Fatal(err)
+			}
+
+			if ch := checkConfigDir(); ch.Status != tt.status {
+				t.Errorf("status = %s, want %s (%s)", ch.Status, tt.status, ch.Message)
+			}
+		})
+	}
+}
+
+func TestCheckConfigFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string // "" leaves the file missing
+		status  string
+	}{
+		{"missing", "", checkPass},
+		{"valid", "version: 2\ntimezone: Europe/Brussels\n", checkPass},
+		{"unknown key", "version: 2\ncolour: blue\n", checkWarn},
+		{"invalid value", "version: 2\nwatch_interval: -5\n", checkWarn},
+		{"broken yaml", "favorites: [\n", checkFail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+
+			if tt.content != "" {
+				if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if ch := checkConfigFile("config file", path); ch.Status != tt.status {
+				t.Errorf("status = %s, want %s (%s: %s)", ch.Status, tt.status, ch.Message, ch.Hint)
+			}
+		})
+	}
+}
+
+func TestCheckDBus(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "bus")
+	if err := os.WriteFile(socket, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		address string
+		status  string
+	}{
+		{"unset", "", checkWarn},
+		{"live socket", "unix:path=" + socket + ",guid=1", checkPass},
+		{"stale socket", "unix:path=/nonexistent/bus", checkFail},
+		{"abstract", "unix:abstract=/tmp/dbus-1", checkPass},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ch := checkDBus(auth.KeyringDiagnostics{DBusAddress: tt.address}); ch.Status != tt.status {
+				t.Errorf("status = %s, want %s (%s)", ch.Status, tt.status, ch.Message)
+			}
+		})
+	}
+}
+
+// lockedFileKeyring stores a key in the file backend and then clears its
+// password, so reading the key would need a prompt.
+func lockedFileKeyring(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("DELIJN_KEYRING_BACKEND", "file")
+	t.Setenv("DELIJN_KEYRING_PASSWORD", "secret")
+	t.Setenv("DELIJN_API_KEY", "")
+
+	store, err := auth.OpenDefault()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.SetAPIKey("key"); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("DELIJN_KEYRING_PASSWORD", "")
+}
+
+func TestCheckStoredKeyDoesNotPrompt(t *testing.T) {
+	lockedFileKeyring(t)
+
+	done := make(chan doctorCheck, 1)
+
+	go func() { done <- checkStoredKey() }()
+
+	select {
+	case ch := <-done:
+		if ch.Status != checkPass || ch.Message != "stored in keyring" {
+			t.Errorf("checkStoredKey() = %s %q", ch.Status, ch.Message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("checkStoredKey() blocked")
+	}
+}
+
+func TestGetAPIKeyNoPromptReportsLocked(t *testing.T) {
+	lockedFileKeyring(t)
+
+	done := make(chan error, 1)
+
+	go func() {
+		_, err := auth.GetAPIKeyNoPrompt()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !auth.IsKeyringLocked(err) {
+			t.Errorf("GetAPIKeyNoPrompt() error = %v, want a locked keyring", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("GetAPIKeyNoPrompt() blocked")
+	}
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/output"
+	"github.com/dedene/delijn-cli/internal/schema"
+)
+
+type EventsCmd struct {
+	Stop     string        `arg:"" required:"" help:"Stop (number, name, or @favorite)"`
+	Interval time.Duration `help:"Polling interval" default:"30s"`
+	Line     string        `help:"Only report events for this line" short:"l"`
+}
+
+func (c *EventsCmd) Run(root *RootFlags) error {
+	output.SetNoColor(root.NoColor)
+
+	if c.Interval < 10*time.Second {
+		return fmt.Errorf("interval must be at least 10s, got %s", c.Interval)
+	}
+
+	client, err := root.newClient()
+	if err != nil {
+		return err
+	}
+
+	resolveCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stopNumber, err := ResolveStop(resolveCtx, client, c.Stop)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if !root.JSON && !root.Plain && term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Fprintf(os.Stderr, "Watching stop %d every %s (Ctrl+C to stop)\n", stopNumber, c.Interval)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+
+	for event := range client.WatchRealtime(ctx, stopNumber, c.Interval) {
+		if c.Line != "" && event.Departure != nil && !matchesLine(*event.Departure, c.Line) {
+			continue
+		}
+
+		switch {
+		case root.JSON || root.Format == output.FormatNDJSON:
+			if err := enc.Encode(schema.NewEvent(event)); err != nil {
+				return fmt.Errorf("write event: %w", err)
+			}
+		case root.Plain:
+			outputEventPlain(event)
+		default:
+			outputEvent(event)
+		}
+	}
+
+	return nil
+}
+
+func outputEventPlain(e api.Event) {
+	if e.Departure == nil {
+		fmt.Fprintf(os.Stdout, "%s\t%s\t\t\t\t%s\n", e.Time.Format(time.RFC3339), e.Type, e.Error)
+
+		return
+	}
+
+	fmt.Fprintf(os.Stdout, "%s\t%s\t%s\t%s\t%d\t%d\n",
+		e.Time.Format(time.RFC3339),
+		e.Type,
+		formatLineNumber(*e.Departure),
+		e.Departure.Destination,
+		e.Delay,
+		e.Change,
+	)
+}
+
+func outputEvent(e api.Event) {
+	stamp := output.Dim(output.FormatTimeWithSeconds(e.Time))
+
+	if e.Departure == nil {
+		fmt.Fprintf(os.Stdout, "%s  %s  %s\n", stamp, output.Red("error"), e.Error)
+
+		return
+	}
+
+	d := *e.Departure
+	subject := fmt.Sprintf("%s %s (%s)", output.Bold(formatLineNumber(d)), d.Destination,
+		output.FormatTime(d.ScheduledTime))
+
+	var detail string
+
+	switch e.Type {
+	case api.EventAdded:
+		detail = output.Green("new departure")
+	case api.EventRealtime:
+		detail = "now realtime, " + output.FormatDelay(e.Delay)
+	case api.EventDelayChanged:
+		detail = fmt.Sprintf("delay %s (%s)", output.FormatDelay(e.Delay), formatDelayChange(e.Change))
+	case api.EventCancelled:
+		detail = output.Red("cancelled")
+	case api.EventDeparted:
+		detail = output.Dim("departed")
+	case api.EventRemoved:
+		detail = output.Yellow("no longer listed")
+	default:
+		detail = string(e.Type)
+	}
+
+	fmt.Fprintf(os.Stdout, "%s  %s  %s\n", stamp, subject, detail)
+}
+
+func formatDelayChange(seconds int) string {
+	if seconds > -60 && seconds < 60 {
+		return fmt.Sprintf("%+ds", seconds)
+	}
+
+	return fmt.Sprintf("%+dm", seconds/60)
+}
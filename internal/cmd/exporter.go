@@ -0,0 +1,337 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/metrics"
+)
+
+// exporterLatencyBuckets are the bounds, in seconds, of the API latency
+// histogram.
+var exporterLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+type ExporterCmd struct {
+	Listen   string        `help:"Address to serve /metrics on" default:":9464"`
+	Stops    []string      `help:"Stops to export, comma separated (number, name, or @favorite); defaults to all favorites" placeholder:"STOP,..."`
+	Interval time.Duration `help:"Refresh interval" default:"30s"`
+	Count    int           `help:"Departures fetched per stop" default:"20" short:"n"`
+}
+
+func (c *ExporterCmd) Run(root *RootFlags) error {
+	if c.Interval < 10*time.Second {
+		return fmt.Errorf("interval must be at least 10s, got %s", c.Interval)
+	}
+
+	if err := checkCount(c.Count); err != nil {
+		return err
+	}
+
+	client, err := root.newLiveClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stops, err := namedStops(ctx, client, c.Stops)
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", c.Listen)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	runCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	m := newExporterMetrics(client)
+	go c.poll(runCtx, client, stops, m)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", m.registry)
+
+	fmt.Fprintf(os.Stderr, "Serving metrics on http://%s/metrics (Ctrl+C to stop)\n", boardURLHost(ln.Addr()))
+
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	served := make(chan error, 1)
+
+	go func() {
+		served <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-served:
+		return fmt.Errorf("serve: %w", err)
+	case <-runCtx.Done():
+	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelShutdown()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shut down: %w", err)
+	}
+
+	return nil
+}
+
+// poll refreshes the departure metrics until ctx is done, backing off like
+// watch mode while the API is throttled.
+func (c *ExporterCmd) poll(ctx context.Context, client *api.Client, stops []namedStop, m *exporterMetrics) {
+	backoff := api.NewBackoff(c.Interval)
+
+	for {
+		results, err := c.refresh(ctx, client, stops)
+		if ctx.Err() != nil {
+			return
+		}
+
+		m.record(results)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff.Next(err)):
+		}
+	}
+}
+
+// refresh fetches the departures of every stop. It returns the first error
+// along with the results.
+func (c *ExporterCmd) refresh(ctx context.Context, client *api.Client, stops []namedStop) ([]stopResult, error) {
+	fetcher := &DeparturesCmd{Count: c.Count}
+	results := make([]stopResult, 0, len(stops))
+
+	var firstErr error
+
+	for _, s := range stops {
+		fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		departures, err := fetcher.fetchDepartures(fetchCtx, client, []int{s.stop})
+
+		cancel()
+
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+
+		results = append(results, stopResult{stop: s.stop, departures: departures, err: err})
+	}
+
+	return results, firstErr
+}
+
+// stopResult is the outcome of fetching the departures of one stop.
+type stopResult struct {
+	stop       int
+	departures []api.Departure
+	err        error
+}
+
+// exporterMetrics are the metrics of `delijn exporter`.
+type exporterMetrics struct {
+	registry *metrics.Registry
+
+	up            *metrics.Gauge
+	departures    *metrics.Gauge
+	delay         *metrics.Gauge
+	delayMax      *metrics.Gauge
+	realtimeRatio *metrics.Gauge
+	cancelled     *metrics.Counter
+
+	apiDuration *metrics.Histogram
+	apiErrors   *metrics.Counter
+	tokens      *metrics.Gauge
+	circuitOpen *metrics.Gauge
+
+	// Cancelled departures already counted, by stop, so one that stays
+	// cancelled across refreshes counts once.
+	seenCancelled map[int]map[string]bool
+}
+
+// newExporterMetrics registers the metrics and hooks them up to client.
+func newExporterMetrics(client *api.Client) *exporterMetrics {
+	r := metrics.NewRegistry()
+	m := &exporterMetrics{
+		registry: r,
+
+		up: r.Gauge("delijn_stop_up",
+			"Whether the last refresh of the stop's departures succeeded.", "stop"),
+		departures: r.Gauge("delijn_line_departures",
+			"Departures of the line at the stop in the last refresh.", "stop", "line"),
+		delay: r.Gauge("delijn_line_delay_seconds",
+			"Mean delay of the line's departures with realtime data at the stop; negative is early.", "stop", "line"),
+		delayMax: r.Gauge("delijn_line_delay_max_seconds",
+			"Largest delay of the line's departures with realtime data at the stop.", "stop", "line"),
+		realtimeRatio: r.Gauge("delijn_stop_realtime_ratio",
+			"Share of the stop's departures with realtime data, from 0 to 1.", "stop"),
+		cancelled: r.Counter("delijn_line_cancelled_total",
+			"Cancelled departures of the line at the stop, including ones that skip it.", "stop", "line"),
+
+		apiDuration: r.Histogram("delijn_api_request_duration_seconds",
+			"Time De Lijn took to answer, including retries.", exporterLatencyBuckets, "api"),
+		apiErrors: r.Counter("delijn_api_errors_total",
+			"Failed De Lijn API requests by reason.", "api", "reason"),
+		tokens: r.Gauge("delijn_ratelimit_tokens",
+			"Requests the client's rate limiter allows right now.", "api"),
+		circuitOpen: r.Gauge("delijn_circuit_breaker_open",
+			"Whether the circuit breaker refuses requests after repeated failures."),
+
+		seenCancelled: map[int]map[string]bool{},
+	}
+
+	client.SetObserver(m.observeRequest)
+	r.OnScrape(func() {
+		limits := client.Limits()
+
+		m.tokens.Set(float64(limits.KernTokens), "kern")
+		m.tokens.Set(float64(limits.SearchTokens), "search")
+		m.circuitOpen.Set(boolValue(limits.CircuitOpen))
+	})
+
+	return m
+}
+
+func (m *exporterMetrics) observeRequest(r api.Request) {
+	product := strings.ToLower(r.Product)
+
+	m.apiDuration.Observe(r.Duration.Seconds(), product)
+
+	if reason := requestErrorReason(r.Status); reason != "" {
+		m.apiErrors.Inc(product, reason)
+	}
+}
+
+// requestErrorReason names what went wrong with a request that got status,
+// or returns "" when it succeeded.
+func requestErrorReason(status int) string {
+	switch {
+	case status == 0:
+		return "network"
+	case status == http.StatusTooManyRequests:
+		return "rate_limited"
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return "auth"
+	case status == http.StatusNotFound:
+		return "not_found"
+	case status >= 500:
+		return "server_error"
+	case status >= 400:
+		return "client_error"
+	}
+
+	return ""
+}
+
+// record replaces the departure metrics with those of results, all at once
+// for scrapes. A stop that failed is down and has no line metrics until it
+// recovers.
+func (m *exporterMetrics) record(results []stopResult) {
+	m.registry.Update(func() { m.replace(results) })
+}
+
+func (m *exporterMetrics) replace(results []stopResult) {
+	type lineStats struct {
+		departures, realtime int
+		delaySum, delayMax   int
+	}
+
+	m.up.Reset()
+	m.departures.Reset()
+	m.delay.Reset()
+	m.delayMax.Reset()
+	m.realtimeRatio.Reset()
+
+	for _, res := range results {
+		stop := strconv.Itoa(res.stop)
+
+		if res.err != nil {
+			m.up.Set(0, stop)
+
+			continue
+		}
+
+		m.up.Set(1, stop)
+
+		lines := map[string]*lineStats{}
+		realtime := 0
+		cancelled := map[string]bool{}
+
+		for _, d := range res.departures {
+			line := formatLineNumber(d)
+
+			stats, ok := lines[line]
+			if !ok {
+				stats = &lineStats{}
+				lines[line] = stats
+				m.cancelled.Add(0, stop, line)
+			}
+
+			stats.departures++
+
+			if d.IsRealTime() {
+				realtime++
+			}
+
+			if d.IsCancelled() || d.SkipsStop() {
+				key := lineKey(d) + "/" + d.ScheduledTime.Format(time.RFC3339)
+				cancelled[key] = true
+
+				if !m.seenCancelled[res.stop][key] {
+					m.cancelled.Inc(stop, line)
+				}
+
+				continue
+			}
+
+			if !d.IsRealTime() {
+				continue
+			}
+
+			delay := d.DelaySeconds()
+			if stats.realtime == 0 || delay > stats.delayMax {
+				stats.delayMax = delay
+			}
+
+			stats.realtime++
+			stats.delaySum += delay
+		}
+
+		// Only departures still listed can show up again.
+		m.seenCancelled[res.stop] = cancelled
+
+		if len(res.departures) > 0 {
+			m.realtimeRatio.Set(float64(realtime)/float64(len(res.departures)), stop)
+		}
+
+		for line, stats := range lines {
+			m.departures.Set(float64(stats.departures), stop, line)
+
+			if stats.realtime > 0 {
+				m.delay.Set(float64(stats.delaySum)/float64(stats.realtime), stop, line)
+				m.delayMax.Set(float64(stats.delayMax), stop, line)
+			}
+		}
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/config"
+)
+
+func TestExporterRecord(t *testing.T) {
+	m := newExporterMetrics(api.NewClientWithKey("test"))
+
+	now := time.Now()
+	dep := func(line, delay int, realtime bool, status ...api.PredictionStatus) api.Departure {
+		d := api.Departure{EntityNumber: 1, LineNumber: line, ScheduledTime: now.Add(time.Duration(line) * time.Minute)}
+		if realtime {
+			rt := d.ScheduledTime.Add(time.Duration(delay) * time.Second)
+			d.RealTime = &rt
+			status = append(status, api.StatusRealtime)
+		}
+
+		d.PredictionStatus = status
+
+		return d
+	}
+
+	results := []stopResult{
+		{stop: 200144, departures: []api.Departure{
+			dep(1, 60, true),
+			dep(1, 180, true),
+			dep(2, 0, false),
+			dep(3, 0, true, api.StatusCancelled),
+		}},
+		{stop: 200145, err: errors.New("boom")},
+	}
+
+	// Recording the same cancelled departure twice counts it once.
+	m.record(results)
+	m.record(results)
+
+	var b strings.Builder
+	if _, err := m.registry.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`delijn_stop_up{stop="200144"} 1`,
+		`delijn_stop_up{stop="200145"} 0`,
+		`delijn_line_departures{stop="200144",line="1"} 2`,
+		`delijn_line_delay_seconds{stop="200144",line="1"} 120`,
+		`delijn_line_delay_max_seconds{stop="200144",line="1"} 180`,
+		`delijn_stop_realtime_ratio{stop="200144"} 0.75`,
+		`delijn_line_cancelled_total{stop="200144",line="1"} 0`,
+		`delijn_line_cancelled_total{stop="200144",line="3"} 1`,
+		`delijn_ratelimit_tokens{api="kern"} 240`,
+		`delijn_circuit_breaker_open 0`,
+	} {
+		if !strings.Contains(b.String(), want+"\n") {
+			t.Errorf("metrics lack %s:\n%s", want, b.String())
+		}
+	}
+
+	if strings.Contains(b.String(), `delijn_line_delay_seconds{stop="200144",line="2"}`) {
+		t.Error("a line without realtime data has a delay")
+	}
+}
+
+func TestExporterRecordReplaces(t *testing.T) {
+	m := newExporterMetrics(api.NewClientWithKey("test"))
+
+	now := time.Now()
+	rt := now.Add(time.Minute)
+	ok := []api.Departure{{
+		EntityNumber: 1, LineNumber: 1, ScheduledTime: now, RealTime: &rt,
+		PredictionStatus: []api.PredictionStatus{api.StatusRealtime},
+	}}
+
+	m.record([]stopResult{{stop: 200144, departures: ok}, {stop: 200145, departures: ok}})
+	m.record([]stopResult{{stop: 200144, departures: ok}, {stop: 200145, err: errors.New("boom")}})
+
+	var b strings.Builder
+	if _, err := m.registry.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+
+	for _, line := range strings.Split(b.String(), "\n") {
+		for _, family := range []string{"delijn_stop_", "delijn_line_departures", "delijn_line_delay"} {
+			if strings.HasPrefix(line, family) {
+				got = append(got, line)
+			}
+		}
+	}
+
+	want := []string{
+		`delijn_stop_up{stop="200144"} 1`,
+		`delijn_stop_up{stop="200145"} 0`,
+		`delijn_line_departures{stop="200144",line="1"} 1`,
+		`delijn_line_delay_seconds{stop="200144",line="1"} 60`,
+		`delijn_line_delay_max_seconds{stop="200144",line="1"} 60`,
+		`delijn_stop_realtime_ratio{stop="200144"} 1`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("samples =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// unreachableLiveClient returns a live client whose requests all fail, while
+// the response cache holds departures of stop 200144.
+func unreachableLiveClient(t *testing.T) *api.Client {
+	t.Helper()
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("DELIJN_API_KEY", "test")
+
+	dir, err := config.CacheDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cached := `{"halteDoorkomsten": [{"haltenummer": 200144, "doorkomsten": [{"lijnnummer": 1,
+		"dienstregelingTijdstip": "2026-10-18T08:00:00"}]}]}`
+	if err := api.NewResponseCache(dir).Store(api.BaseURLKern+"/haltes/2/200144/real-time", []byte(cached)); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := (&RootFlags{}).newLiveClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.SetTransport(roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("network is unreachable")
+	}))
+
+	return client
+}
+
+func TestExporterStopDownWhenAPIUnreachable(t *testing.T) {
+	// A cached answer must not make the stop look up.
+	client := unreachableLiveClient(t)
+
+	c := &ExporterCmd{Count: 20}
+	m := newExporterMetrics(client)
+
+	results, err := c.refresh(context.Background(), client, []namedStop{{stop: 200144}})
+	if err == nil {
+		t.Fatal("refresh() succeeded without the API")
+	}
+
+	m.record(results)
+
+	var b strings.Builder
+	if _, err := m.registry.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(b.String(), `delijn_stop_up{stop="200144"} 0`+"\n") ||
+		strings.Contains(b.String(), "delijn_line_departures{") {
+		t.Errorf("metrics with the API down:\n%s", b.String())
+	}
+
+	if _, err := (&RootFlags{Offline: true}).newLiveClient(); err == nil {
+		t.Error("newLiveClient() accepted --offline")
+	}
+}
+
+func TestRequestErrorReason(t *testing.T) {
+	for status, want := range map[int]string{
+		http.StatusOK:                  "",
+		0:                              "network",
+		http.StatusTooManyRequests:     "rate_limited",
+		http.StatusForbidden:           "auth",
+		http.StatusNotFound:            "not_found",
+		http.StatusBadRequest:          "client_error",
+		http.StatusServiceUnavailable:  "server_error",
+		http.StatusInternalServerError: "server_error",
+	} {
+		if got := requestErrorReason(status); got != want {
+			t.Errorf("requestErrorReason(%d) = %q, want %q", status, got, want)
+		}
+	}
+}
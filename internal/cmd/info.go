@@ -6,47 +6,99 @@ import (
 
 	"github.com/dedene/delijn-cli/internal/auth"
 	"github.com/dedene/delijn-cli/internal/config"
+	"github.com/dedene/delijn-cli/internal/output"
 )
 
 type InfoCmd struct{}
 
-func (c *InfoCmd) Run() error {
-	configPath, _ := config.ConfigPath()
-	keyringDir, _ := config.KeyringDir()
+// infoDocument is the output of `delijn info` in structured formats.
+type infoDocument struct {
+	Version        string `json:"version"`
+	ConfigPath     string `json:"config_path"`
+	SharedConfig   string `json:"shared_config,omitempty"`
+	Profile        string `json:"profile,omitempty"`
+	KeyringDir     string `json:"keyring_dir"`
+	KeyringBackend string `json:"keyring_backend,omitempty"`
+	APIKey         string `json:"api_key"`
+}
+
+var infoFields = []output.Field{
+	{Name: "config_path", Header: "Config path", Table: true, Plain: true},
+	{Name: "shared_config", Header: "Shared config", Table: true, Plain: true},
+	{Name: "profile", Header: "Profile", Table: true, Plain: true},
+	{Name: "keyring_dir", Header: "Keyring dir", Table: true, Plain: true},
+	{Name: "keyring_backend", Header: "Keyring backend", Table: true, Plain: true},
+	{Name: "api_key", Header: "API key", Table: true, Plain: true},
+	{Name: "version", Header: "Version"},
+}
 
-	fmt.Fprintf(os.Stdout, "De Lijn CLI - %s\n", VersionString())
-	fmt.Fprintln(os.Stdout)
-	fmt.Fprintf(os.Stdout, "Config path:     %s\n", configPath)
-	fmt.Fprintf(os.Stdout, "Keyring dir:     %s\n", keyringDir)
+func (c *InfoCmd) Run(root *RootFlags) error {
+	output.SetNoColor(root.NoColor)
+
+	doc := infoDocument{Version: VersionString()}
+	doc.ConfigPath, _ = config.ConfigPath()
+	doc.SharedConfig, _ = config.SharedConfigPath()
+	doc.Profile = config.ActiveProfile()
+	doc.KeyringDir, _ = config.KeyringDir()
 
 	backendInfo, err := auth.ResolveKeyringBackendInfo()
 	if err == nil {
-		fmt.Fprintf(os.Stdout, "Keyring backend: %s (source: %s)\n", backendInfo.Value, backendInfo.Source)
+		doc.KeyringBackend = fmt.Sprintf("%s (source: %s)", backendInfo.Value, backendInfo.Source)
 	}
 
 	// Check API key status
 	store, err := auth.OpenDefault()
 	if err != nil {
-		fmt.Fprintf(os.Stdout, "API key:         error opening keyring: %v\n", err)
+		doc.APIKey = fmt.Sprintf("error opening keyring: %v", err)
 	} else {
 		hasKey, checkErr := store.HasAPIKey()
 
 		switch {
 		case checkErr != nil:
-			fmt.Fprintf(os.Stdout, "API key:         error checking: %v\n", checkErr)
+			doc.APIKey = fmt.Sprintf("error checking: %v", checkErr)
 		case hasKey:
-			fmt.Fprintln(os.Stdout, "API key:         configured")
+			doc.APIKey = "configured"
 		default:
-			fmt.Fprintln(os.Stdout, "API key:         not configured")
+			doc.APIKey = "not configured"
 		}
 	}
 
-	fmt.Fprintln(os.Stdout)
-	fmt.Fprintln(os.Stdout, "API endpoints:")
-	fmt.Fprintln(os.Stdout, "  Core:   https://api.delijn.be/DLKernOpenData/v1/beta (240 req/min)")
-	fmt.Fprintln(os.Stdout, "  Search: https://api.delijn.be/DLZoekOpenData/v1/beta (6000 req/min)")
-	fmt.Fprintln(os.Stdout)
-	fmt.Fprintln(os.Stdout, "Get your API key from https://data.delijn.be/")
+	listing := output.Listing{
+		Document: doc,
+		Fields:   infoFields,
+		Records: []output.Record{{
+			Item: doc,
+			Values: map[string]string{
+				"version":         doc.Version,
+				"config_path":     doc.ConfigPath,
+				"shared_config":   doc.SharedConfig,
+				"profile":         doc.Profile,
+				"keyring_dir":     doc.KeyringDir,
+				"keyring_backend": doc.KeyringBackend,
+				"api_key":         doc.APIKey,
+			},
+		}},
+		Details: true,
+	}
+
+	banner := root.Format == output.FormatTable && len(root.Fields) == 0
+	if banner {
+		fmt.Fprintf(os.Stdout, "De Lijn CLI - %s\n", doc.Version)
+		fmt.Fprintln(os.Stdout)
+	}
+
+	if err := root.render(listing); err != nil {
+		return err
+	}
+
+	if banner {
+		fmt.Fprintln(os.Stdout)
+		fmt.Fprintln(os.Stdout, "API endpoints:")
+		fmt.Fprintln(os.Stdout, "  Core:   https://api.delijn.be/DLKernOpenData/v1/beta (240 req/min)")
+		fmt.Fprintln(os.Stdout, "  Search: https://api.delijn.be/DLZoekOpenData/v1/beta (6000 req/min)")
+		fmt.Fprintln(os.Stdout)
+		fmt.Fprintln(os.Stdout, "Get your API key from https://data.delijn.be/")
+	}
 
 	return nil
 }
@@ -0,0 +1,281 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/config"
+	"github.com/dedene/delijn-cli/internal/output"
+	"github.com/dedene/delijn-cli/internal/schema"
+)
+
+// leaveFetch is how many departures leave looks at to find --count that can
+// still be caught.
+const leaveFetch = 30
+
+type LeaveCmd struct {
+	Stop  string        `arg:"" help:"Stop (number, name, or @favorite)"`
+	Walk  time.Duration `help:"Walking time to the stop, e.g. 6m; defaults to the favorite's walk time" short:"w"`
+	Line  string        `help:"Filter by line number" short:"l"`
+	Count int           `help:"Maximum number of departures" default:"5" short:"n"`
+	Wait  bool          `help:"Wait until it is time to leave for the first departure, then ring the terminal bell"`
+	Hook  string        `help:"Shell command to run when it is time to leave, with --wait"`
+}
+
+func (c *LeaveCmd) Run(root *RootFlags) error {
+	output.SetNoColor(root.NoColor)
+
+	if c.Walk < 0 {
+		return &ExitError{Code: 2, Err: fmt.Errorf("--walk cannot be negative, got %s", c.Walk)}
+	}
+
+	if c.Hook != "" && !c.Wait {
+		return &ExitError{Code: 2, Err: errors.New("--hook needs --wait")}
+	}
+
+	if err := checkCount(c.Count); err != nil {
+		return err
+	}
+
+	client, err := root.newClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stop, err := ResolveStop(ctx, client, c.Stop)
+	if err != nil {
+		return err
+	}
+
+	walk, err := c.walkTime()
+	if err != nil {
+		return err
+	}
+
+	departures, err := c.fetch(ctx, client, stop, walk)
+	if err != nil {
+		return err
+	}
+
+	if err := root.render(leaveListing(stop, walk, departures)); err != nil {
+		return err
+	}
+
+	if !c.Wait {
+		return nil
+	}
+
+	if len(departures) == 0 {
+		return errors.New("no departure to wait for")
+	}
+
+	return c.wait(client, stop, walk, departures[0])
+}
+
+// walkTime is --walk or, for a favorite, its walk time.
+func (c *LeaveCmd) walkTime() (time.Duration, error) {
+	alias, ok := strings.CutPrefix(c.Stop, "@")
+	if c.Walk != 0 || !ok {
+		return c.Walk, nil
+	}
+
+	fav, err := config.LookupFavorite(alias)
+	if err != nil {
+		return 0, fmt.Errorf("resolve favorite %q: %w", alias, err)
+	}
+
+	return fav.WalkTime(), nil
+}
+
+// departures fetches the departures at stop that run, filtered by --line.
+func (c *LeaveCmd) departures(ctx context.Context, client *api.Client, stop int) ([]api.Departure, error) {
+	fetcher := &DeparturesCmd{Count: leaveFetch, Line: c.Line, HideCancelled: true}
+
+	return fetcher.fetchDepartures(ctx, client, []int{stop})
+}
+
+// fetch returns up to --count departures that can still be caught by
+// leaving for the stop now or later.
+func (c *LeaveCmd) fetch(ctx context.Context, client *api.Client, stop int, walk time.Duration) ([]api.Departure, error) {
+	departures, err := c.departures(ctx, client, stop)
+	if err != nil {
+		return nil, err
+	}
+
+	departures = catchable(departures, walk, time.Now())
+	if len(departures) > c.Count {
+		departures = departures[:max(c.Count, 0)]
+	}
+
+	return departures, nil
+}
+
+// catchable keeps the departures that leave at least walk after now.
+func catchable(departures []api.Departure, walk time.Duration, now time.Time) []api.Departure {
+	out := make([]api.Departure, 0, len(departures))
+
+	for _, d := range departures {
+		if !d.ExpectedTime().Add(-walk).Before(now) {
+			out = append(out, d)
+		}
+	}
+
+	return out
+}
+
+// wait blocks until it is time to leave for target, refreshing every 30
+// seconds so a delay moves the alarm. If target is cancelled, the next
+// catchable departure takes its place.
+func (c *LeaveCmd) wait(client *api.Client, stop int, walk time.Duration, target api.Departure) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	fmt.Fprintf(os.Stderr, "Waiting to leave at %s for %s\n", output.FormatTime(target.ExpectedTime().Add(-walk)), describeDeparture(target))
+
+	refresh := time.NewTicker(watchInterval)
+	defer refresh.Stop()
+
+	for {
+		alarm := time.NewTimer(time.Until(target.ExpectedTime().Add(-walk)))
+
+		select {
+		case <-ctx.Done():
+			alarm.Stop()
+
+			return nil
+		case <-alarm.C:
+			return c.leaveNow(ctx, stop, walk, target)
+		case <-refresh.C:
+			alarm.Stop()
+
+			fetchCtx, cancelFetch := context.WithTimeout(ctx, 30*time.Second)
+			next, err := c.follow(fetchCtx, client, stop, walk, target)
+
+			cancelFetch()
+
+			switch {
+			case err != nil:
+				// Keep the last known time; the next refresh may work.
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			case next.Key() != target.Key():
+				fmt.Fprintf(os.Stderr, "%s no longer runs; waiting for %s\n", describeDeparture(target), describeDeparture(next))
+			case !next.ExpectedTime().Equal(target.ExpectedTime()):
+				fmt.Fprintf(os.Stderr, "Leave at %s now (%s)\n", output.FormatTime(next.ExpectedTime().Add(-walk)), describeDeparture(next))
+			}
+
+			if err == nil {
+				target = next
+			}
+		}
+	}
+}
+
+// follow returns target as refreshed, or the first catchable departure when
+// target is cancelled or no longer listed.
+func (c *LeaveCmd) follow(ctx context.Context, client *api.Client, stop int, walk time.Duration, target api.Departure) (api.Departure, error) {
+	departures, err := c.departures(ctx, client, stop)
+	if err != nil {
+		return api.Departure{}, err
+	}
+
+	for _, d := range departures {
+		if d.Key() == target.Key() {
+			return d, nil
+		}
+	}
+
+	if next := catchable(departures, walk, time.Now()); len(next) > 0 {
+		return next[0], nil
+	}
+
+	return api.Departure{}, errors.New("no departure left to catch")
+}
+
+// leaveNow rings the terminal bell and runs --hook.
+func (c *LeaveCmd) leaveNow(ctx context.Context, stop int, walk time.Duration, d api.Departure) error {
+	fmt.Fprintf(os.Stderr, "\aLeave now for %s\n", describeDeparture(d))
+
+	if c.Hook == "" {
+		return nil
+	}
+
+	hook := exec.CommandContext(ctx, "sh", "-c", c.Hook) //nolint:gosec // user-supplied hook
+	hook.Stdout, hook.Stderr = os.Stdout, os.Stderr
+	hook.Env = append(os.Environ(),
+		"DELIJN_STOP="+strconv.Itoa(stop),
+		"DELIJN_LINE="+formatLineNumber(d),
+		"DELIJN_DESTINATION="+d.Destination,
+		"DELIJN_DEPARTURE="+output.FormatTime(d.ExpectedTime()),
+		"DELIJN_WALK="+walk.String(),
+	)
+
+	if err := hook.Run(); err != nil {
+		return fmt.Errorf("run hook: %w", err)
+	}
+
+	return nil
+}
+
+func describeDeparture(d api.Departure) string {
+	return fmt.Sprintf("line %s to %s at %s", formatLineNumber(d), d.Destination, output.FormatTime(d.ExpectedTime()))
+}
+
+var leaveFields = []output.Field{
+	{Name: "leave", Header: "LEAVE", Table: true, Plain: true},
+	{Name: "leave_in", Header: "LEAVE IN", Table: true},
+	{Name: "line", Header: "LINE", Table: true, Plain: true},
+	{Name: "destination", Header: "DESTINATION", Table: true, Plain: true},
+	{Name: "departs", Header: "DEPARTS", Table: true, Plain: true},
+	{Name: "delay", Header: "DELAY", Table: true, Plain: true},
+	{Name: "direction", Header: "DIRECTION"},
+	{Name: "type", Header: "TYPE"},
+}
+
+func leaveListing(stop int, walk time.Duration, departures []api.Departure) output.Listing {
+	doc := schema.NewLeave(stop, walk, departures)
+	records := make([]output.Record, 0, len(departures))
+
+	for i, d := range departures {
+		leaveAt := doc.Departures[i].LeaveAt
+		leaveIn := output.FormatRelative(leaveAt)
+
+		styledIn := leaveIn
+		if time.Until(leaveAt) < 5*time.Minute {
+			styledIn = output.Bold(output.Yellow(leaveIn))
+		}
+
+		records = append(records, output.Record{
+			Item: doc.Departures[i],
+			Values: map[string]string{
+				"leave":       output.FormatTime(leaveAt),
+				"leave_in":    leaveIn,
+				"line":        formatLineNumber(d),
+				"destination": d.Destination,
+				"departs":     output.FormatTime(d.ExpectedTime()),
+				"delay":       strconv.Itoa(d.DelaySeconds()),
+				"direction":   d.Direction,
+				"type":        d.TransportType,
+			},
+			Styled: map[string]string{"leave_in": styledIn, "delay": formatDelayStr(d)},
+		})
+	}
+
+	return output.Listing{
+		Document: doc,
+		Fields:   leaveFields,
+		Records:  records,
+		Empty:    "No departures left to catch.",
+	}
+}
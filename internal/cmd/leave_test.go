@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/output"
+)
+
+func TestCatchable(t *testing.T) {
+	now := time.Now()
+	departure := func(line int, in time.Duration) api.Departure {
+		return api.Departure{LineNumber: line, ScheduledTime: now.Add(in)}
+	}
+
+	departures := []api.Departure{
+		departure(1, 2*time.Minute),
+		departure(2, 6*time.Minute),
+		departure(3, 9*time.Minute),
+	}
+
+	tests := []struct {
+		walk time.Duration
+		want []int
+	}{
+		{0, []int{1, 2, 3}},
+		{6 * time.Minute, []int{2, 3}},
+		{10 * time.Minute, nil},
+	}
+
+	for _, tt := range tests {
+		var lines []int
+		for _, d := range catchable(departures, tt.walk, now) {
+			lines = append(lines, d.LineNumber)
+		}
+
+		if !slices.Equal(lines, tt.want) {
+			t.Errorf("walk %s: lines = %v, want %v", tt.walk, lines, tt.want)
+		}
+	}
+}
+
+func TestLeaveListing(t *testing.T) {
+	departs := time.Date(2026, 10, 18, 17, 58, 0, 0, time.Local)
+	d := api.Departure{LineNumber: 1, LinePublicNumber: "1", Destination: "Flanders Expo", ScheduledTime: departs}
+
+	l := leaveListing(200144, 6*time.Minute, []api.Departure{d})
+
+	values := l.Records[0].Values
+	if want := departs.Add(-6 * time.Minute); values["leave"] != output.FormatTime(want) {
+		t.Errorf("leave = %q, want %q", values["leave"], output.FormatTime(want))
+	}
+
+	if values["departs"] != output.FormatTime(departs) {
+		t.Errorf("departs = %q, want %q", values["departs"], output.FormatTime(departs))
+	}
+}
@@ -3,12 +3,12 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"os"
-	"text/tabwriter"
+	"strconv"
 	"time"
 
 	"github.com/dedene/delijn-cli/internal/api"
 	"github.com/dedene/delijn-cli/internal/output"
+	"github.com/dedene/delijn-cli/internal/schema"
 )
 
 type LinesCmd struct {
@@ -18,15 +18,21 @@ type LinesCmd struct {
 
 type LinesSearchCmd struct {
 	Query string `arg:"" required:"" help:"Line number or name"`
+	Where string `help:"Filter expression, e.g. 'type == \"TRAM\" && entity == 2'"`
 }
 
 func (c *LinesSearchCmd) Run(root *RootFlags) error {
 	output.SetNoColor(root.NoColor)
 
+	where, err := compileWhere(c.Where, lineFilterFields)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	client, err := api.NewClient()
+	client, err := root.newClient()
 	if err != nil {
 		return err
 	}
@@ -36,19 +42,15 @@ func (c *LinesSearchCmd) Run(root *RootFlags) error {
 		return fmt.Errorf("search lines: %w", err)
 	}
 
-	if root.JSON {
-		return outputJSON(resp.Lines)
-	}
-
-	if root.Plain {
-		outputLinesPlain(resp.Lines)
+	lines := make([]api.Line, 0, len(resp.Lines))
 
-		return nil
+	for _, l := range resp.Lines {
+		if where.Match(lineFilterRecord(l)) {
+			lines = append(lines, l)
+		}
 	}
 
-	outputLinesTable(resp.Lines)
-
-	return nil
+	return root.render(linesListing(lines))
 }
 
 type LinesGetCmd struct {
@@ -62,7 +64,7 @@ func (c *LinesGetCmd) Run(root *RootFlags) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	client, err := api.NewClient()
+	client, err := root.newClient()
 	if err != nil {
 		return err
 	}
@@ -72,70 +74,58 @@ func (c *LinesGetCmd) Run(root *RootFlags) error {
 		return fmt.Errorf("get line: %w", err)
 	}
 
-	if root.JSON {
-		return outputJSON(line)
-	}
-
-	if root.Plain {
-		outputLinePlain(line)
-
-		return nil
-	}
-
-	outputLineDetails(line)
-
-	return nil
+	return root.render(lineDetailsListing(*line))
 }
 
-func outputLinesTable(lines []api.Line) {
-	if len(lines) == 0 {
-		fmt.Fprintln(os.Stdout, "No lines found.")
-
-		return
-	}
-
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	defer w.Flush()
+var lineFields = []output.Field{
+	{Name: "entity", Header: "ENTITY", Table: true, Plain: true},
+	{Name: "number", Header: "NUMBER", Table: true, Plain: true},
+	{Name: "line", Header: "PUBLIC", Table: true, Plain: true},
+	{Name: "type", Header: "TYPE", Table: true, Plain: true},
+	{Name: "description", Header: "DESCRIPTION", Table: true, Plain: true},
+}
 
-	fmt.Fprintln(w, "ENTITY\tNUMBER\tPUBLIC\tTYPE\tDESCRIPTION")
+// lineDetailsFields are the labels of `lines get`.
+var lineDetailsFields = []output.Field{
+	{Name: "entity", Header: "Entity", Table: true, Plain: true},
+	{Name: "number", Header: "Number", Table: true, Plain: true},
+	{Name: "line", Header: "Line", Table: true, Plain: true},
+	{Name: "type", Header: "Type", Table: true, Plain: true},
+	{Name: "description", Header: "Description", Table: true, Plain: true},
+}
 
-	for _, l := range lines {
-		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%s\n",
-			l.EntityNumber,
-			l.LineNumber,
-			l.PublicNumber,
-			l.TransportType,
-			l.Description,
-		)
+func lineRecord(l api.Line) output.Record {
+	return output.Record{
+		Item: schema.NewLine(l),
+		Values: map[string]string{
+			"entity":      strconv.Itoa(l.EntityNumber),
+			"number":      strconv.Itoa(l.LineNumber),
+			"line":        l.PublicNumber,
+			"type":        l.TransportType,
+			"description": l.Description,
+		},
 	}
 }
 
-func outputLinesPlain(lines []api.Line) {
+func linesListing(lines []api.Line) output.Listing {
+	records := make([]output.Record, 0, len(lines))
 	for _, l := range lines {
-		fmt.Fprintf(os.Stdout, "%d\t%d\t%s\t%s\t%s\n",
-			l.EntityNumber,
-			l.LineNumber,
-			l.PublicNumber,
-			l.TransportType,
-			l.Description,
-		)
+		records = append(records, lineRecord(l))
 	}
-}
 
-func outputLinePlain(line *api.Line) {
-	fmt.Fprintf(os.Stdout, "%d\t%d\t%s\t%s\t%s\n",
-		line.EntityNumber,
-		line.LineNumber,
-		line.PublicNumber,
-		line.TransportType,
-		line.Description,
-	)
+	return output.Listing{
+		Document: schema.NewLines(lines),
+		Fields:   lineFields,
+		Records:  records,
+		Empty:    "No lines found.",
+	}
 }
 
-func outputLineDetails(line *api.Line) {
-	fmt.Fprintf(os.Stdout, "Line:        %s\n", line.PublicNumber)
-	fmt.Fprintf(os.Stdout, "Description: %s\n", line.Description)
-	fmt.Fprintf(os.Stdout, "Type:        %s\n", line.TransportType)
-	fmt.Fprintf(os.Stdout, "Entity:      %d\n", line.EntityNumber)
-	fmt.Fprintf(os.Stdout, "Number:      %d\n", line.LineNumber)
+func lineDetailsListing(line api.Line) output.Listing {
+	return output.Listing{
+		Document: schema.NewLineDetails(line),
+		Fields:   lineDetailsFields,
+		Records:  []output.Record{lineRecord(line)},
+		Details:  true,
+	}
 }
@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/config"
+	"github.com/dedene/delijn-cli/internal/notify"
+	"github.com/dedene/delijn-cli/internal/output"
+)
+
+type NotifyCmd struct {
+	Stops       []string      `arg:"" optional:"" name:"stop" help:"Stops to watch (number, name, or @favorite); defaults to all favorites"`
+	Line        string        `help:"Filter by line number" short:"l"`
+	When        time.Duration `help:"Notify when a departure is this close, e.g. 5m"`
+	Delay       time.Duration `help:"Notify when a departure runs this late; 0 disables" default:"5m"`
+	Disruptions bool          `help:"Notify about disruptions at the stop and departures that are cancelled, skip the stop or are diverted" default:"true" negatable:""`
+	Once        bool          `help:"Exit after the first --when notification"`
+	Count       int           `help:"Departures checked per stop" default:"10" short:"n"`
+}
+
+func (c *NotifyCmd) Run(root *RootFlags) error {
+	if c.When < 0 || c.Delay < 0 {
+		return &ExitError{Code: 2, Err: errors.New("--when and --delay cannot be negative")}
+	}
+
+	if c.Once && c.When == 0 {
+		return &ExitError{Code: 2, Err: errors.New("--once needs --when")}
+	}
+
+	if err := checkCount(c.Count); err != nil {
+		return err
+	}
+
+	// notify_hook runs a command, so a shared delijn.yaml cannot set it.
+	cfg, err := config.ReadTrustedConfig()
+	if err != nil {
+		return err
+	}
+
+	notifier, err := notify.New(cfg.NotifyHook)
+	if err != nil {
+		return err
+	}
+
+	client, err := root.newClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stops, err := namedStops(ctx, client, c.Stops)
+	if err != nil {
+		return err
+	}
+
+	return c.watch(client, notifier, stops)
+}
+
+// watch polls stops every watchInterval, backing off while the API is
+// throttled, and posts the alerts of each refresh.
+func (c *NotifyCmd) watch(client *api.Client, notifier notify.Notifier, stops []namedStop) error {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	fetcher := &DeparturesCmd{Count: c.Count, Line: c.Line}
+	alerts := newAlerter(c.When, c.Delay, c.Disruptions)
+	backoff := api.NewBackoff(watchInterval)
+
+	for {
+		var fetchErr error
+
+		for _, s := range stops {
+			fetchCtx, cancelFetch := context.WithTimeout(ctx, 30*time.Second)
+			departures, err := fetcher.fetchDepartures(fetchCtx, client, []int{s.stop})
+
+			cancelFetch()
+
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+
+				fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", s.title, err)
+				fetchErr = err
+
+				continue
+			}
+
+			found := alerts.check(s, departures, time.Now())
+
+			if c.Disruptions {
+				disruptions, err := c.fetchDisruptions(ctx, client, s.stop)
+
+				switch {
+				case ctx.Err() != nil:
+					return nil
+				case err != nil:
+					// Keep the known disruptions so they aren't notified again.
+					fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", s.title, err)
+					fetchErr = err
+				default:
+					found = append(found, alerts.checkDisruptions(s, disruptions)...)
+				}
+			}
+
+			for _, a := range found {
+				fmt.Fprintf(os.Stderr, "%s %s: %s\n", output.FormatTime(time.Now()), a.Summary, a.Body)
+
+				if err := notifier.Notify(ctx, a.Notification); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				}
+
+				if c.Once && a.due {
+					return nil
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff.Next(fetchErr)):
+		}
+	}
+}
+
+func (c *NotifyCmd) fetchDisruptions(ctx context.Context, client *api.Client, stop int) ([]api.Disruption, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	disruptions, err := client.GetStopDisruptions(ctx, stop)
+	if err != nil {
+		return nil, fmt.Errorf("disruptions: %w", err)
+	}
+
+	return disruptions, nil
+}
+
+// alert is a notification about one departure or disruption.
+type alert struct {
+	notify.Notification
+	due bool // the --when countdown, not a disruption or delay
+}
+
+// alerter decides which departures to notify about, each reason at most
+// once per departure and stop.
+type alerter struct {
+	when        time.Duration
+	delay       time.Duration
+	disruptions bool
+	sent        map[int]map[string]bool // stop -> departure key + reason
+	notices     map[int]map[string]bool // stop -> disruption ID
+}
+
+func newAlerter(when, delay time.Duration, disruptions bool) *alerter {
+	return &alerter{
+		when:        when,
+		delay:       delay,
+		disruptions: disruptions,
+		sent:        map[int]map[string]bool{},
+		notices:     map[int]map[string]bool{},
+	}
+}
+
+// check returns the new alerts for the departures at s. Departures that are
+// no longer listed are forgotten.
+func (a *alerter) check(s namedStop, departures []api.Departure, now time.Time) []alert {
+	prev := a.sent[s.stop]
+	sent := make(map[string]bool, len(prev))
+	out := []alert{}
+
+	add := func(d api.Departure, reason string, n alert) {
+		key := d.Key() + "/" + reason
+
+		sent[key] = true
+		if !prev[key] {
+			out = append(out, n)
+		}
+	}
+
+	for _, d := range departures {
+		title := fmt.Sprintf("Line %s to %s", formatLineNumber(d), d.Destination)
+		at := fmt.Sprintf("%s at %s", s.title, output.FormatTime(d.ExpectedTime()))
+
+		if a.disruptions && (d.IsCancelled() || d.SkipsStop() || d.IsDiverted()) {
+			add(d, d.Status(), alert{Notification: notify.Notification{
+				Summary: title + " " + disruptionText(d),
+				Body:    at,
+				Urgent:  true,
+			}})
+
+			continue
+		}
+
+		late := time.Duration(d.DelaySeconds()) * time.Second
+		if a.delay > 0 && late >= a.delay {
+			add(d, "delay", alert{Notification: notify.Notification{
+				Summary: fmt.Sprintf("%s is %d min late", title, int(late.Minutes())),
+				Body:    at,
+			}})
+		}
+
+		left := d.ExpectedTime().Sub(now)
+		if a.when > 0 && left > 0 && left <= a.when {
+			add(d, "due", alert{Notification: notify.Notification{
+				Summary: fmt.Sprintf("%s in %d min", title, int(left.Round(time.Minute).Minutes())),
+				Body:    at,
+			}, due: true})
+		}
+	}
+
+	a.sent[s.stop] = sent
+
+	return out
+}
+
+// checkDisruptions returns alerts for the disruptions at s that were not
+// listed at the previous check.
+func (a *alerter) checkDisruptions(s namedStop, disruptions []api.Disruption) []alert {
+	prev := a.notices[s.stop]
+	seen := make(map[string]bool, len(disruptions))
+	out := []alert{}
+
+	for _, d := range disruptions {
+		key := d.ID
+		if key == "" {
+			key = d.Title
+		}
+
+		seen[key] = true
+		if prev[key] {
+			continue
+		}
+
+		summary := d.Title
+		if summary == "" {
+			summary = "Disruption"
+		}
+
+		out = append(out, alert{Notification: notify.Notification{
+			Summary: summary,
+			Body:    s.title,
+			Urgent:  true,
+		}})
+	}
+
+	a.notices[s.stop] = seen
+
+	return out
+}
+
+func disruptionText(d api.Departure) string {
+	switch {
+	case d.IsCancelled():
+		return "is cancelled"
+	case d.SkipsStop():
+		return "skips the stop"
+	default:
+		return "is diverted"
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/notify"
+	"github.com/dedene/delijn-cli/internal/output"
+)
+
+// fakeNotifier records notifications instead of showing them.
+type fakeNotifier struct {
+	sent []notify.Notification
+}
+
+func (f *fakeNotifier) Notify(_ context.Context, n notify.Notification) error {
+	f.sent = append(f.sent, n)
+
+	return nil
+}
+
+func TestAlerterCheck(t *testing.T) {
+	now := time.Date(2026, 10, 18, 8, 0, 0, 0, output.BrusselsTimezone())
+	departure := func(line int, in, late time.Duration, statuses ...api.PredictionStatus) api.Departure {
+		scheduled := now.Add(in)
+		expected := scheduled.Add(late)
+
+		return api.Departure{
+			LineNumber:       line,
+			Destination:      "Flanders Expo",
+			ScheduledTime:    scheduled,
+			ScheduledTimeRaw: scheduled.Format("2006-01-02T15:04:05"),
+			RealTime:         &expected,
+			PredictionStatus: statuses,
+		}
+	}
+
+	home := namedStop{title: "@home (200144)", stop: 200144}
+	a := newAlerter(5*time.Minute, 5*time.Minute, true)
+	f := &fakeNotifier{}
+
+	check := func(departures ...api.Departure) {
+		for _, n := range a.check(home, departures, now) {
+			_ = f.Notify(context.Background(), n.Notification)
+		}
+	}
+
+	due := departure(1, 4*time.Minute, 0)
+	later := departure(2, 20*time.Minute, 0)
+	cancelled := departure(3, 10*time.Minute, 0, api.StatusCancelled)
+	late := departure(4, 10*time.Minute, 7*time.Minute)
+
+	check(due, later, cancelled, late)
+
+	want := []notify.Notification{
+		{Summary: "Line 1 to Flanders Expo in 4 min", Body: "@home (200144) at 08:04"},
+		{Summary: "Line 3 to Flanders Expo is cancelled", Body: "@home (200144) at 08:10", Urgent: true},
+		{Summary: "Line 4 to Flanders Expo is 7 min late", Body: "@home (200144) at 08:17"},
+	}
+	if !slices.Equal(f.sent, want) {
+		t.Fatalf("sent = %+v\nwant %+v", f.sent, want)
+	}
+
+	check(due, later, cancelled, late)
+
+	if len(f.sent) != len(want) {
+		t.Errorf("second check notified again: %+v", f.sent[len(want):])
+	}
+
+	// A departure that drops out and comes back is new again.
+	check(later)
+	check(due)
+
+	if len(f.sent) != len(want)+1 {
+		t.Errorf("sent %d notifications, want %d", len(f.sent), len(want)+1)
+	}
+}
+
+func TestAlerterDisabled(t *testing.T) {
+	now := time.Now()
+	expected := now.Add(10 * time.Minute)
+	d := api.Departure{
+		LineNumber:       1,
+		ScheduledTime:    now,
+		RealTime:         &expected,
+		PredictionStatus: []api.PredictionStatus{api.StatusDiverted},
+	}
+
+	if got := newAlerter(0, 0, false).check(namedStop{stop: 1}, []api.Departure{d}, now); len(got) != 0 {
+		t.Errorf("check() = %+v, want no alerts", got)
+	}
+}
+
+func TestAlerterCheckDisruptions(t *testing.T) {
+	home := namedStop{title: "@home (200144)", stop: 200144}
+	a := newAlerter(0, 0, true)
+
+	works := api.Disruption{ID: "1", Title: "Works on Korenmarkt"}
+	strike := api.Disruption{ID: "2", Title: "Strike"}
+
+	if got := a.checkDisruptions(home, []api.Disruption{works}); len(got) != 1 ||
+		got[0].Summary != "Works on Korenmarkt" || got[0].Body != home.title || !got[0].Urgent {
+		t.Fatalf("first check = %+v", got)
+	}
+
+	if got := a.checkDisruptions(home, []api.Disruption{works, strike}); len(got) != 1 || got[0].Summary != "Strike" {
+		t.Errorf("second check = %+v, want only the new disruption", got)
+	}
+
+	// A disruption that is lifted and comes back is new again.
+	a.checkDisruptions(home, []api.Disruption{strike})
+
+	if got := a.checkDisruptions(home, []api.Disruption{works, strike}); len(got) != 1 || got[0].Summary != "Works on Korenmarkt" {
+		t.Errorf("after lifting = %+v", got)
+	}
+}
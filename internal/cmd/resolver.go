@@ -3,6 +3,8 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -53,6 +55,68 @@ func ResolveStop(ctx context.Context, client *api.Client, ref string) (int, erro
 	}
 }
 
+// perronSuffix matches the platform part of a stop name, e.g. " perron 3".
+var perronSuffix = regexp.MustCompile(`(?i)\s+perron\s+\S+$`)
+
+// stationName strips the platform from a stop name, so that all perrons of a
+// station share it.
+func stationName(description string) string {
+	return perronSuffix.ReplaceAllString(strings.TrimSpace(description), "")
+}
+
+// ResolveArea resolves a stop reference to every stop of its station: the
+// stops in the same municipality whose names match up to the perron. Names
+// pick the best search match as the station instead of failing when several
+// stops match.
+func ResolveArea(ctx context.Context, client *api.Client, ref string) ([]api.Stop, error) {
+	var station api.Stop
+
+	if _, err := strconv.Atoi(ref); err == nil || strings.HasPrefix(ref, "@") {
+		stopNum, err := ResolveStop(ctx, client, ref)
+		if err != nil {
+			return nil, err
+		}
+
+		stop, err := client.GetStopByNumber(ctx, stopNum)
+		if err != nil {
+			return nil, fmt.Errorf("get stop %d: %w", stopNum, err)
+		}
+
+		station = *stop
+	} else {
+		resp, err := client.SearchStops(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("search stops: %w", err)
+		}
+
+		if len(resp.Stops) == 0 {
+			return nil, fmt.Errorf("no stops found matching %q", ref)
+		}
+
+		station = resp.Stops[0]
+	}
+
+	name := stationName(station.Description)
+
+	resp, err := client.SearchStops(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("search stops: %w", err)
+	}
+
+	stops := []api.Stop{station}
+
+	for _, s := range resp.Stops {
+		if s.Number != station.Number && s.Municipality == station.Municipality &&
+			strings.EqualFold(stationName(s.Description), name) {
+			stops = append(stops, s)
+		}
+	}
+
+	sort.Slice(stops, func(i, j int) bool { return stops[i].Number < stops[j].Number })
+
+	return stops, nil
+}
+
 // AmbiguousStopError is returned when multiple stops match a query.
 type AmbiguousStopError struct {
 	Query string
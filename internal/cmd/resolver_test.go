@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/dedene/delijn-cli/internal/api"
+)
+
+func TestStationName(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Gent Sint-Pietersstation perron 3", "Gent Sint-Pietersstation"},
+		{"Antwerpen Centraal Station Perron A1", "Antwerpen Centraal Station"},
+		{"Gent Korenmarkt", "Gent Korenmarkt"},
+		{"Perronstraat", "Perronstraat"},
+	}
+
+	for _, tt := range tests {
+		if got := stationName(tt.in); got != tt.want {
+			t.Errorf("stationName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDeparturesListingStopColumn(t *testing.T) {
+	deps := []api.Departure{{StopNumber: 200552, LineNumber: 1}}
+
+	tests := []struct {
+		stops    []int
+		wantStop bool
+	}{
+		{[]int{200552}, false},
+		{[]int{200552, 200553}, true},
+	}
+
+	for _, tt := range tests {
+		l := departuresListing(tt.stops, deps, false)
+
+		var shown bool
+
+		for _, f := range l.Fields {
+			if f.Name == "stop" {
+				shown = f.Table
+			}
+		}
+
+		if shown != tt.wantStop {
+			t.Errorf("%d stops: STOP column shown = %v, want %v", len(tt.stops), shown, tt.wantStop)
+		}
+	}
+
+	for _, f := range departureFields {
+		if f.Name == "stop" && f.Table {
+			t.Error("departuresListing must not modify departureFields")
+		}
+	}
+}
@@ -3,31 +3,194 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"time"
 
 	"github.com/alecthomas/kong"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/config"
+	"github.com/dedene/delijn-cli/internal/output"
 )
 
 type RootFlags struct {
-	JSON    bool `help:"Output JSON to stdout"`
-	Plain   bool `help:"Output plain TSV (for scripting)"`
-	NoColor bool `help:"Disable colors" env:"NO_COLOR"`
+	JSON     bool     `help:"Output JSON to stdout (same as --format json)"`
+	Plain    bool     `help:"Output plain TSV for scripting (same as --format plain)"`
+	Format   string   `help:"Output format: ${enum}" enum:"table,plain,json,ndjson,csv,yaml,markdown,template" default:"table"`
+	Template string   `help:"Go template applied to each item, e.g. '{{.Line}} {{.Destination}}' (implies --format template)"`
+	Fields   []string `help:"Comma-separated columns for table, plain, csv and markdown output" sep:","`
+	NoColor  bool     `help:"Disable colors" env:"NO_COLOR"`
+	Config   string   `help:"Path to user config file" env:"DELIJN_CONFIG" type:"path" placeholder:"PATH"`
+	Profile  string   `help:"Config profile to apply on top of the user config" env:"DELIJN_PROFILE"`
+	Offline  bool     `help:"Use the responses cached by earlier runs instead of the network" env:"DELIJN_OFFLINE"`
+
+	client *api.Client // set by newClient, for the cached data banner
+}
+
+// newClient returns an API client that caches its responses, so they can be
+// served when the network is down or with --offline.
+func (r *RootFlags) newClient() (*api.Client, error) {
+	client, err := api.NewClient()
+
+	switch {
+	case err == nil:
+	case r.Offline:
+		// Cached responses need no API key.
+		client = api.NewClientWithKey("")
+	default:
+		return nil, err
+	}
+
+	if dir, err := config.CacheDir(); err == nil {
+		client.SetCache(api.NewResponseCache(dir))
+	}
+
+	client.SetOffline(r.Offline)
+	r.client = client
+
+	return client, nil
+}
+
+// newLiveClient is newClient without the response cache, for views that
+// must show the API as down rather than old timetables as current.
+func (r *RootFlags) newLiveClient() (*api.Client, error) {
+	if r.Offline {
+		return nil, &ExitError{Code: 2, Err: errors.New("--offline is not supported: this command only shows live data")}
+	}
+
+	client, err := r.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	client.SetCache(nil)
+
+	return client, nil
+}
+
+// render writes l to stdout in the selected output format.
+func (r *RootFlags) render(l output.Listing) error {
+	return r.renderTo(os.Stdout, l)
+}
+
+// renderTo writes l to w in the selected output format.
+func (r *RootFlags) renderTo(w io.Writer, l output.Listing) error {
+	return output.Render(w, r.Format, l, output.Options{Fields: r.Fields, Template: r.Template})
 }
 
 type CLI struct {
 	RootFlags `embed:""`
 
 	Version    kong.VersionFlag `help:"Print version and exit"`
+	JSONSchema JSONSchemaFlag   `name:"json-schema" help:"Print the JSON Schema of the command's --json output and exit"`
 	VersionCmd VersionCmd       `cmd:"" name:"version" help:"Print version"`
 	Auth       AuthCmd          `cmd:"" help:"Manage API key"`
 	Config     ConfigCmd        `cmd:"" help:"Manage configuration"`
 	Stops      StopsCmd         `cmd:"" help:"Search and view stops"`
 	Lines      LinesCmd         `cmd:"" help:"Search and view lines"`
 	Departures DeparturesCmd    `cmd:"" help:"Show realtime departures"`
+	Events     EventsCmd        `cmd:"" help:"Stream departure changes at a stop"`
+	Trip       TripCmd          `cmd:"" help:"Show the remaining stops of a trip"`
+	Leave      LeaveCmd         `cmd:"" help:"Show when to leave for the next departures"`
+	Dashboard  DashboardCmd     `cmd:"" help:"Full-screen departures of favorite stops"`
+	Notify     NotifyCmd        `cmd:"" help:"Desktop notifications for departures and disruptions"`
+	WatchRules WatchRulesCmd    `cmd:"" name:"watch-rules" help:"Run the hooks in config when their rules match"`
+	Serve      ServeCmd         `cmd:"" help:"Serve departures, stops and lines as a local JSON API"`
+	Board      BoardCmd         `cmd:"" help:"Serve a live departure board web page"`
+	Exporter   ExporterCmd      `cmd:"" help:"Export departure delays as Prometheus metrics"`
 	Info       InfoCmd          `cmd:"" help:"Show CLI and API info"`
+	Doctor     DoctorCmd        `cmd:"" help:"Diagnose config, keyring and API connectivity"`
 	Completion CompletionCmd    `cmd:"" help:"Generate shell completions"`
 }
 
+// BeforeResolve points the config package at --config and --profile, then
+// registers the resolver that fills unset flags from DELIJN_* variables and
+// the config defaults section.
+func (cli *CLI) BeforeResolve(kctx *kong.Context) error {
+	for _, flag := range kctx.Flags() {
+		value, _ := kctx.FlagValue(flag).(string)
+
+		switch flag.Name {
+		case "config":
+			config.SetConfigPath(value)
+		case "profile":
+			config.SetProfile(value)
+		}
+	}
+
+	cfg, err := config.ReadConfig()
+	if err != nil {
+		// Leave config errors to the command; `config edit` must still run.
+		return nil //nolint:nilerr // reported when the command reads config
+	}
+
+	// Kong calls the resolver's Validate, which warns about unknown keys.
+	kctx.AddResolver(newFlagDefaultsResolver(cfg))
+
+	return nil
+}
+
+// AfterApply reconciles --json, --plain, --template and --format, so commands
+// can check either the shorthands or Format.
+func (cli *CLI) AfterApply() error {
+	switch {
+	case cli.JSON:
+		cli.Format = output.FormatJSON
+	case cli.Plain:
+		cli.Format = output.FormatPlain
+	case cli.Template != "" && cli.Format == output.FormatTable:
+		cli.Format = output.FormatTemplate
+	}
+
+	cli.JSON = cli.Format == output.FormatJSON
+	cli.Plain = cli.Format == output.FormatPlain
+
+	if cli.Format == output.FormatTemplate && cli.Template == "" {
+		return errors.New("--format template needs --template")
+	}
+
+	return nil
+}
+
+// AfterRun tells the user when the output was built from cached responses.
+// Falling back to the cache exits with ExitCached, so scripts can tell stale
+// data apart; --offline asks for it and exits 0.
+func (cli *CLI) AfterRun() error {
+	if cli.client == nil {
+		return nil
+	}
+
+	since, ok := cli.client.CachedSince()
+	if !ok {
+		return nil
+	}
+
+	banner := cachedBanner(time.Since(since))
+
+	if cli.Offline {
+		fmt.Fprintln(os.Stderr, banner)
+
+		return nil
+	}
+
+	return &ExitError{Code: api.ExitCached, Err: errors.New(banner)}
+}
+
+// cachedBanner describes data cached age ago.
+func cachedBanner(age time.Duration) string {
+	ago := "less than a minute ago"
+
+	switch minutes := int(age.Minutes()); {
+	case minutes == 1:
+		ago = "1 minute ago"
+	case minutes > 1:
+		ago = fmt.Sprintf("%d minutes ago", minutes)
+	}
+
+	return "Offline: showing data cached " + ago + "."
+}
+
 type exitPanic struct{ code int }
 
 func Execute(args []string) (err error) {
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCachedBanner(t *testing.T) {
+	tests := []struct {
+		age  time.Duration
+		want string
+	}{
+		{20 * time.Second, "cached less than a minute ago"},
+		{90 * time.Second, "cached 1 minute ago"},
+		{42 * time.Minute, "cached 42 minutes ago"},
+	}
+
+	for _, tt := range tests {
+		if got := cachedBanner(tt.age); !strings.Contains(got, tt.want) {
+			t.Errorf("cachedBanner(%s) = %q, want it to contain %q", tt.age, got, tt.want)
+		}
+	}
+}
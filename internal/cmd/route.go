@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/dedene/delijn-cli/internal/api"
+)
+
+// routeFilter keeps departures whose trip calls at a destination stop after
+// the stop it leaves from. The destination text of a departure is usually the
+// terminus, so the line direction's stop sequence is checked instead. Each
+// sequence is fetched once and kept across watch refreshes.
+type routeFilter struct {
+	to        int
+	sequences map[string][]int
+}
+
+func newRouteFilter(to int) *routeFilter {
+	return &routeFilter{to: to, sequences: map[string][]int{}}
+}
+
+// reaches reports whether d gets to the destination stop. A nil filter
+// accepts every departure.
+func (f *routeFilter) reaches(ctx context.Context, client *api.Client, d api.Departure) (bool, error) {
+	if f == nil {
+		return true, nil
+	}
+
+	key := fmt.Sprintf("%d/%d/%s", d.EntityNumber, d.LineNumber, d.Direction)
+
+	sequence, ok := f.sequences[key]
+	if !ok {
+		stops, err := client.GetLineDirectionStops(ctx, d.EntityNumber, d.LineNumber, d.Direction)
+		if err != nil {
+			return false, fmt.Errorf("get stops of line %s: %w", formatLineNumber(d), err)
+		}
+
+		for _, s := range stops {
+			sequence = append(sequence, s.Number)
+		}
+
+		f.sequences[key] = sequence
+	}
+
+	return callsAfter(sequence, d.StopNumber, f.to), nil
+}
+
+// callsAfter reports whether sequence visits to after from. When from is not
+// in the sequence, visiting to at all is enough.
+func callsAfter(sequence []int, from, to int) bool {
+	start := slices.Index(sequence, from)
+
+	return slices.Contains(sequence[start+1:], to)
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import "testing"
+
+func TestCallsAfter(t *testing.T) {
+	sequence := []int{200100, 200200, 200300, 200400}
+
+	tests := []struct {
+		from, to int
+		want     bool
+	}{
+		{200200, 200400, true},
+		{200300, 200200, false},
+		{200300, 200300, false},
+		{200400, 200100, false},
+		{999999, 200100, true},
+		{200100, 999999, false},
+	}
+
+	for _, tt := range tests {
+		if got := callsAfter(sequence, tt.from, tt.to); got != tt.want {
+			t.Errorf("callsAfter(%d, %d) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
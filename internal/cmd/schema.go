@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/kong"
+
+	"github.com/dedene/delijn-cli/internal/schema"
+)
+
+// commandSchemas maps command paths to the schema of their --json output.
+var commandSchemas = map[string]string{
+	"departures":            "departures",
+	"events":                "event",
+	"trip":                  "trip",
+	"leave":                 "leave",
+	"watch-rules":           "hook",
+	"stops search":          "stops",
+	"stops get":             "stop",
+	"lines search":          "lines",
+	"lines get":             "line",
+	"config list-favorites": "favorites",
+}
+
+// JSONSchemaFlag prints the JSON Schema of the selected command's --json
+// output and exits. Like --version, it runs before required arguments are
+// checked, so `delijn departures --json-schema` works without a stop.
+type JSONSchemaFlag bool
+
+func (f JSONSchemaFlag) BeforeReset(kctx *kong.Context, app *kong.Kong) error {
+	path := commandPath(kctx.Selected())
+
+	name, ok := commandSchemas[path]
+	if !ok {
+		if path == "" {
+			path = app.Model.Name
+		}
+
+		return fmt.Errorf("%s has no JSON schema; available for: %s", path, strings.Join(schemaCommands(), ", "))
+	}
+
+	if name == "departures" {
+		if groupBy, _ := flagValue(kctx, "group-by").(string); groupBy != "" {
+			name = "board"
+		}
+
+		if watch, _ := flagValue(kctx, "watch").(bool); watch {
+			name = "watch"
+		}
+	}
+
+	b, err := schema.Lookup(name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(app.Stdout, string(b))
+	app.Exit(0)
+
+	return nil
+}
+
+// commandPath returns the space-separated command names leading to node,
+// e.g. "stops get".
+func commandPath(node *kong.Node) string {
+	var names []string
+
+	for n := node; n != nil && n.Parent != nil; n = n.Parent {
+		if n.Type == kong.CommandNode {
+			names = append([]string{n.Name}, names...)
+		}
+	}
+
+	return strings.Join(names, " ")
+}
+
+// flagValue returns the parsed value of the flag called name, or nil.
+func flagValue(kctx *kong.Context, name string) any {
+	for _, flag := range kctx.Flags() {
+		if flag.Name == name {
+			return kctx.FlagValue(flag)
+		}
+	}
+
+	return nil
+}
+
+func schemaCommands() []string {
+	paths := make([]string, 0, len(commandSchemas))
+	for path := range commandSchemas {
+		paths = append(paths, path)
+	}
+
+	slices.Sort(paths)
+
+	return paths
+}
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/dedene/delijn-cli/internal/schema"
+)
+
+func TestCommandSchemasExist(t *testing.T) {
+	names := []string{"watch", "board"}
+	for _, name := range commandSchemas {
+		names = append(names, name)
+	}
+
+	for _, name := range names {
+		if _, err := schema.Lookup(name); err != nil {
+			t.Errorf("schema.Lookup(%q) error: %v", name, err)
+		}
+	}
+}
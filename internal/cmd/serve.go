@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/config"
+	"github.com/dedene/delijn-cli/internal/schema"
+	"github.com/dedene/delijn-cli/internal/server"
+)
+
+// How long `delijn serve` keeps answers. Realtime departures go stale
+// quickly; stops and lines rarely change.
+const (
+	serveDeparturesTTL  = 15 * time.Second
+	serveDisruptionsTTL = 5 * time.Minute
+	serveStaticTTL      = time.Hour
+)
+
+type ServeCmd struct {
+	Addr  string `help:"Address to listen on" default:"127.0.0.1:8080" env:"DELIJN_SERVE_ADDR"`
+	Token string `help:"Require this bearer token on every request" env:"DELIJN_SERVE_TOKEN"`
+}
+
+func (c *ServeCmd) Run(root *RootFlags) error {
+	client, err := root.newClient()
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", c.Addr)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if host, _, _ := net.SplitHostPort(c.Addr); c.Token == "" && !isLoopback(host) {
+		fmt.Fprintln(os.Stderr, "Warning: serving without --token on a non-loopback address; anyone on the network can use your API key")
+	}
+
+	fmt.Fprintf(os.Stderr, "Serving on http://%s (Ctrl+C to stop)\n", ln.Addr())
+
+	return newServer(client, c.Token).Run(ctx, ln)
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+// newServer routes the REST API to client. Every request goes through the
+// client's rate limiter and circuit breaker.
+func newServer(client *api.Client, token string) *server.Server {
+	srv := server.New(token)
+
+	srv.Handle("GET /departures/{stop}", serveDeparturesTTL, func(r *http.Request) (any, error) {
+		return serveDepartures(r, client)
+	})
+
+	srv.Handle("GET /stops/search", serveStaticTTL, func(r *http.Request) (any, error) {
+		query := r.URL.Query().Get("q")
+		if query == "" {
+			return nil, server.Errorf(http.StatusBadRequest, "missing query parameter q")
+		}
+
+		resp, err := client.SearchStops(r.Context(), query)
+		if err != nil {
+			return nil, fmt.Errorf("search stops: %w", err)
+		}
+
+		return schema.NewStops(resp.Stops), nil
+	})
+
+	srv.Handle("GET /lines/{entity}/{line}", serveStaticTTL, func(r *http.Request) (any, error) {
+		entity, err1 := strconv.Atoi(r.PathValue("entity"))
+		number, err2 := strconv.Atoi(r.PathValue("line"))
+
+		if err1 != nil || err2 != nil {
+			return nil, server.Errorf(http.StatusBadRequest, "entity and line must be numbers")
+		}
+
+		line, err := client.GetLine(r.Context(), entity, number)
+		if err != nil {
+			return nil, fmt.Errorf("get line: %w", err)
+		}
+
+		return schema.NewLineDetails(*line), nil
+	})
+
+	// Favorites come from the config file, so they are never stale.
+	srv.Handle("GET /favorites", 0, func(_ *http.Request) (any, error) {
+		return serveFavorites()
+	})
+
+	srv.Handle("GET /disruptions", serveDisruptionsTTL, func(r *http.Request) (any, error) {
+		return serveDisruptions(r, client)
+	})
+
+	return srv
+}
+
+// serveDepartures answers like `delijn departures <stop> --json`, with
+// optional line, count and hide_cancelled query parameters.
+func serveDepartures(r *http.Request, client *api.Client) (any, error) {
+	q := r.URL.Query()
+	fetcher := &DeparturesCmd{Count: 10, Line: q.Get("line")}
+
+	if v := q.Get("count"); v != "" {
+		count, err := strconv.Atoi(v)
+		if err != nil || count < 1 || count > 100 {
+			return nil, server.Errorf(http.StatusBadRequest, "count must be a number from 1 to 100, got %q", v)
+		}
+
+		fetcher.Count = count
+	}
+
+	if v := q.Get("hide_cancelled"); v != "" {
+		hide, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, server.Errorf(http.StatusBadRequest, "hide_cancelled must be true or false, got %q", v)
+		}
+
+		fetcher.HideCancelled = hide
+	}
+
+	stop, err := serveResolveStop(r.Context(), client, r.PathValue("stop"))
+	if err != nil {
+		return nil, err
+	}
+
+	departures, err := fetcher.fetchDepartures(r.Context(), client, []int{stop})
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.NewDepartures([]int{stop}, departures), nil
+}
+
+// serveResolveStop resolves ref like the CLI, answering 404 for unknown
+// favorites and 400 for names that match several stops.
+func serveResolveStop(ctx context.Context, client *api.Client, ref string) (int, error) {
+	stop, err := ResolveStop(ctx, client, ref)
+
+	var ambiguous *AmbiguousStopError
+
+	switch {
+	case errors.Is(err, config.ErrFavoriteNotFound):
+		return 0, &server.Error{Status: http.StatusNotFound, Err: err}
+	case errors.As(err, &ambiguous):
+		return 0, server.Errorf(http.StatusBadRequest, "%d stops match %q; use a stop number", len(ambiguous.Stops), ref)
+	}
+
+	return stop, err
+}
+
+func serveFavorites() (schema.Favorites, error) {
+	favorites, err := config.ListFavorites()
+	if err != nil {
+		return schema.Favorites{}, fmt.Errorf("list favorites: %w", err)
+	}
+
+	return favoritesDocument(favorites), nil
+}
+
+// serveDisruptions lists the disruptions at the stops given as stop query
+// parameters, or at every favorite.
+func serveDisruptions(r *http.Request, client *api.Client) (any, error) {
+	var stops []int
+
+	if refs := r.URL.Query()["stop"]; len(refs) > 0 {
+		for _, ref := range refs {
+			stop, err := serveResolveStop(r.Context(), client, ref)
+			if err != nil {
+				return nil, err
+			}
+
+			stops = append(stops, stop)
+		}
+	} else {
+		named, err := namedStops(r.Context(), client, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range named {
+			stops = append(stops, s.stop)
+		}
+	}
+
+	doc := schema.Disruptions{SchemaVersion: schema.Version, Stops: stops, Disruptions: []schema.Disruption{}}
+
+	for _, stop := range stops {
+		disruptions, err := client.GetStopDisruptions(r.Context(), stop)
+		if err != nil {
+			return nil, fmt.Errorf("get disruptions at %d: %w", stop, err)
+		}
+
+		for _, d := range disruptions {
+			doc.Disruptions = append(doc.Disruptions, schema.NewDisruption(stop, d))
+		}
+	}
+
+	return doc, nil
+}
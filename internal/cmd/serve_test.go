@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dedene/delijn-cli/internal/api"
+)
+
+func TestServeValidatesRequests(t *testing.T) {
+	srv := newServer(api.NewClientWithKey("test"), "")
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/stops/search", http.StatusBadRequest},
+		{"/lines/two/1", http.StatusBadRequest},
+		{"/departures/200144?count=0", http.StatusBadRequest},
+		{"/departures/200144?hide_cancelled=maybe", http.StatusBadRequest},
+		{"/trips", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		if rec.Code != tt.want {
+			t.Errorf("GET %s: status = %d, want %d (%s)", tt.path, rec.Code, tt.want, rec.Body)
+		}
+	}
+}
+
+func TestIsLoopback(t *testing.T) {
+	for host, want := range map[string]bool{
+		"127.0.0.1": true,
+		"::1":       true,
+		"localhost": true,
+		"0.0.0.0":   false,
+		"":          false,
+		"10.0.0.5":  false,
+	} {
+		if got := isLoopback(host); got != want {
+			t.Errorf("isLoopback(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
@@ -6,10 +6,11 @@ import (
 	"fmt"
 	"os"
 	"strconv"
-	"text/tabwriter"
 	"time"
 
 	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/output"
+	"github.com/dedene/delijn-cli/internal/schema"
 )
 
 type StopsCmd struct {
@@ -19,13 +20,19 @@ type StopsCmd struct {
 
 type StopsSearchCmd struct {
 	Query string `arg:"" required:"" help:"Search query (stop name)"`
+	Where string `help:"Filter expression, e.g. 'municipality == \"Gent\"'"`
 }
 
 func (c *StopsSearchCmd) Run(root *RootFlags) error {
+	where, err := compileWhere(c.Where, stopFilterFields)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	client, err := api.NewClient()
+	client, err := root.newClient()
 	if err != nil {
 		return err
 	}
@@ -35,19 +42,15 @@ func (c *StopsSearchCmd) Run(root *RootFlags) error {
 		return fmt.Errorf("search stops: %w", err)
 	}
 
-	if root.JSON {
-		return outputJSON(resp.Stops)
-	}
-
-	if root.Plain {
-		outputStopsPlain(resp.Stops)
+	stops := make([]api.Stop, 0, len(resp.Stops))
 
-		return nil
+	for _, s := range resp.Stops {
+		if where.Match(stopFilterRecord(s)) {
+			stops = append(stops, s)
+		}
 	}
 
-	outputStopsTable(resp.Stops)
-
-	return nil
+	return root.render(stopsListing(stops))
 }
 
 type StopsGetCmd struct {
@@ -63,7 +66,7 @@ func (c *StopsGetCmd) Run(root *RootFlags) error {
 		return fmt.Errorf("invalid stop number %q: must be a 6-digit number", c.Number)
 	}
 
-	client, err := api.NewClient()
+	client, err := root.newClient()
 	if err != nil {
 		return err
 	}
@@ -73,19 +76,7 @@ func (c *StopsGetCmd) Run(root *RootFlags) error {
 		return fmt.Errorf("get stop: %w", err)
 	}
 
-	if root.JSON {
-		return outputJSON(stop)
-	}
-
-	if root.Plain {
-		outputStopPlain(stop)
-
-		return nil
-	}
-
-	outputStopDetails(stop)
-
-	return nil
+	return root.render(stopDetailsListing(*stop))
 }
 
 func outputJSON(v any) error {
@@ -95,41 +86,57 @@ func outputJSON(v any) error {
 	return enc.Encode(v)
 }
 
-func outputStopsTable(stops []api.Stop) {
-	if len(stops) == 0 {
-		fmt.Fprintln(os.Stdout, "No stops found.")
-
-		return
-	}
+var stopFields = []output.Field{
+	{Name: "number", Header: "NUMBER", Table: true, Plain: true},
+	{Name: "name", Header: "NAME", Table: true, Plain: true},
+	{Name: "municipality", Header: "MUNICIPALITY", Table: true, Plain: true},
+	{Name: "entity", Header: "ENTITY"},
+	{Name: "location", Header: "LOCATION"},
+}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	defer w.Flush()
+// stopDetailsFields are the labels of `stops get`, in display order.
+var stopDetailsFields = []output.Field{
+	{Name: "number", Header: "Number", Table: true, Plain: true},
+	{Name: "name", Header: "Stop", Table: true, Plain: true},
+	{Name: "municipality", Header: "Municipality", Table: true, Plain: true},
+	{Name: "entity", Header: "Entity", Table: true},
+	{Name: "location", Header: "Location", Table: true},
+}
 
-	fmt.Fprintln(w, "NUMBER\tNAME\tMUNICIPALITY")
+func stopRecord(s api.Stop) output.Record {
+	values := map[string]string{
+		"number":       strconv.Itoa(s.Number),
+		"name":         s.Description,
+		"municipality": s.Municipality,
+		"entity":       strconv.Itoa(s.EntityNumber),
+	}
 
-	for _, s := range stops {
-		fmt.Fprintf(w, "%d\t%s\t%s\n", s.Number, s.Description, s.Municipality)
+	if s.GeoCoordinate != nil {
+		values["location"] = fmt.Sprintf("%.6f, %.6f", s.GeoCoordinate.Latitude, s.GeoCoordinate.Longitude)
 	}
+
+	return output.Record{Item: schema.NewStop(s), Values: values}
 }
 
-func outputStopsPlain(stops []api.Stop) {
+func stopsListing(stops []api.Stop) output.Listing {
+	records := make([]output.Record, 0, len(stops))
 	for _, s := range stops {
-		fmt.Fprintf(os.Stdout, "%d\t%s\t%s\n", s.Number, s.Description, s.Municipality)
+		records = append(records, stopRecord(s))
 	}
-}
 
-func outputStopPlain(stop *api.Stop) {
-	fmt.Fprintf(os.Stdout, "%d\t%s\t%s\n", stop.Number, stop.Description, stop.Municipality)
+	return output.Listing{
+		Document: schema.NewStops(stops),
+		Fields:   stopFields,
+		Records:  records,
+		Empty:    "No stops found.",
+	}
 }
 
-func outputStopDetails(stop *api.Stop) {
-	fmt.Fprintf(os.Stdout, "Stop:         %s\n", stop.Description)
-	fmt.Fprintf(os.Stdout, "Number:       %d\n", stop.Number)
-	fmt.Fprintf(os.Stdout, "Municipality: %s\n", stop.Municipality)
-	fmt.Fprintf(os.Stdout, "Entity:       %d\n", stop.EntityNumber)
-
-	if stop.GeoCoordinate != nil {
-		fmt.Fprintf(os.Stdout, "Location:     %.6f, %.6f\n",
-			stop.GeoCoordinate.Latitude, stop.GeoCoordinate.Longitude)
+func stopDetailsListing(stop api.Stop) output.Listing {
+	return output.Listing{
+		Document: schema.NewStopDetails(stop),
+		Fields:   stopDetailsFields,
+		Records:  []output.Record{stopRecord(stop)},
+		Details:  true,
 	}
 }
@@ -0,0 +1,252 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/output"
+	"github.com/dedene/delijn-cli/internal/schema"
+)
+
+// passedSlack is how long a call stays listed after its expected time, so the
+// stop the vehicle is at does not vanish early.
+const passedSlack = time.Minute
+
+type TripCmd struct {
+	Trip   string `arg:"" optional:"" help:"Trip ID, from the TRIP column of 'departures --with-trip-ids'"`
+	Stop   string `help:"Stop the departure leaves from (number, name, or @favorite)" short:"s"`
+	Line   string `help:"Line of the departure at --stop" short:"l"`
+	At     string `help:"Scheduled time of the departure at --stop (HH:MM)"`
+	Follow bool   `help:"Keep refreshing every 30 seconds" short:"f"`
+
+	line  string // public line number
+	names map[int]string
+}
+
+func (c *TripCmd) Run(root *RootFlags) error {
+	output.SetNoColor(root.NoColor)
+
+	client, err := root.newClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	ref, from, err := c.resolveTrip(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	// The public line number and stop names are niceties: without them the
+	// output shows internal numbers.
+	if l, err := client.GetLine(ctx, ref.Entity, ref.Line); err == nil {
+		c.line = l.PublicNumber
+	}
+
+	c.names = map[int]string{}
+	if stops, err := client.GetLineDirectionStops(ctx, ref.Entity, ref.Line, ref.Direction); err == nil {
+		for _, s := range stops {
+			c.names[s.Number] = s.Description
+		}
+	}
+
+	if !c.Follow {
+		trip, err := c.fetch(ctx, client, ref, from)
+		if err != nil {
+			return err
+		}
+
+		return root.render(tripListing(trip))
+	}
+
+	return c.follow(client, ref, from, root)
+}
+
+// resolveTrip finds the trip from its ID, or from --stop, --line and --at.
+// It also returns the stop the trip is followed from, or 0.
+func (c *TripCmd) resolveTrip(ctx context.Context, client *api.Client) (api.TripRef, int, error) {
+	if c.Trip != "" {
+		ref, err := api.ParseTripID(c.Trip)
+		if err != nil {
+			return api.TripRef{}, 0, &ExitError{Code: 2, Err: err}
+		}
+
+		return ref, 0, nil
+	}
+
+	if c.Stop == "" || c.Line == "" || c.At == "" {
+		return api.TripRef{}, 0, &ExitError{Code: 2, Err: errors.New("give a trip ID, or --stop, --line and --at")}
+	}
+
+	stop, err := ResolveStop(ctx, client, c.Stop)
+	if err != nil {
+		return api.TripRef{}, 0, err
+	}
+
+	resp, err := client.GetRealtimeByNumber(ctx, stop)
+	if err != nil {
+		return api.TripRef{}, 0, fmt.Errorf("get departures: %w", err)
+	}
+
+	for _, passage := range resp.StopPassages {
+		for _, d := range passage.Departures {
+			d.ParseTimes()
+
+			if !matchesLine(d, c.Line) || output.FormatTime(d.ScheduledTime) != c.At {
+				continue
+			}
+
+			ref, ok := d.TripRef()
+			if !ok {
+				return api.TripRef{}, 0, fmt.Errorf("De Lijn has no trip number for line %s at %s", c.Line, c.At)
+			}
+
+			return ref, stop, nil
+		}
+	}
+
+	return api.TripRef{}, 0, fmt.Errorf("no departure of line %s scheduled at %s from stop %d", c.Line, c.At, stop)
+}
+
+// fetch returns the trip document with the calls still to come, starting at
+// from when it is set.
+func (c *TripCmd) fetch(ctx context.Context, client *api.Client, ref api.TripRef, from int) (schema.Trip, error) {
+	trip, err := client.GetTrip(ctx, ref)
+	if err != nil {
+		return schema.Trip{}, err
+	}
+
+	return schema.NewTrip(ref, c.line, remainingPassages(trip.Passages, from, time.Now()), c.names), nil
+}
+
+// remainingPassages drops the calls before from, when set, and those the
+// vehicle has passed.
+func remainingPassages(passages []api.TripPassage, from int, now time.Time) []api.TripPassage {
+	for i, p := range passages {
+		if p.Stop == from {
+			passages = passages[i:]
+
+			break
+		}
+	}
+
+	remaining := make([]api.TripPassage, 0, len(passages))
+
+	for _, p := range passages {
+		if p.ExpectedTime().Add(passedSlack).After(now) {
+			remaining = append(remaining, p)
+		}
+	}
+
+	return remaining
+}
+
+func (c *TripCmd) follow(client *api.Client, ref api.TripRef, from int, root *RootFlags) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	// Like departures --watch: JSON is streamed one document per line, a
+	// terminal table is redrawn.
+	streaming := root.Format == output.FormatJSON || root.Format == output.FormatNDJSON
+	redraw := root.Format == output.FormatTable && term.IsTerminal(int(os.Stdout.Fd()))
+	enc := json.NewEncoder(os.Stdout)
+
+	for {
+		fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		trip, err := c.fetch(fetchCtx, client, ref, from)
+
+		cancel()
+
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		case streaming:
+			if err := enc.Encode(trip); err != nil {
+				return fmt.Errorf("encode JSON: %w", err)
+			}
+		default:
+			if redraw {
+				fmt.Fprint(os.Stdout, "\033[2J\033[H")
+			}
+
+			if err := root.render(tripListing(trip)); err != nil {
+				return err
+			}
+		}
+
+		// The trip is over once every call has passed.
+		if err == nil && len(trip.Stops) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+var tripFields = []output.Field{
+	{Name: "stop", Header: "STOP", Table: true, Plain: true},
+	{Name: "name", Header: "NAME", Table: true, Plain: true},
+	{Name: "scheduled", Header: "SCHEDULED", Table: true, Plain: true},
+	{Name: "eta", Header: "ETA", Table: true, Plain: true},
+	{Name: "in", Header: "IN", Table: true},
+	{Name: "delay", Header: "DELAY", Table: true, Plain: true},
+	{Name: "status", Header: "STATUS", Plain: true},
+}
+
+func tripListing(trip schema.Trip) output.Listing {
+	records := make([]output.Record, 0, len(trip.Stops))
+
+	for _, s := range trip.Stops {
+		delay := output.Dim("scheduled")
+		if s.Realtime {
+			delay = output.FormatDelay(s.DelaySeconds)
+		}
+
+		switch s.Status {
+		case "cancelled":
+			delay = output.Red("CANCELLED")
+		case "stop_skipped":
+			delay = output.Red("STOP SKIPPED")
+		}
+
+		records = append(records, output.Record{
+			Item: s,
+			Values: map[string]string{
+				"stop":      strconv.Itoa(s.Stop),
+				"name":      s.Name,
+				"scheduled": output.FormatTime(s.ScheduledTime),
+				"eta":       output.FormatTime(s.ExpectedTime),
+				"in":        output.FormatRelative(s.ExpectedTime),
+				"delay":     strconv.Itoa(s.DelaySeconds),
+				"status":    s.Status,
+			},
+			Styled: map[string]string{"delay": delay},
+		})
+	}
+
+	return output.Listing{
+		Document: trip,
+		Fields:   tripFields,
+		Records:  records,
+		Empty:    "This trip has no calls left.",
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+)
+
+func TestRemainingPassages(t *testing.T) {
+	now := time.Now()
+	passage := func(stop int, in time.Duration) api.TripPassage {
+		return api.TripPassage{Stop: stop, Departure: api.Departure{ScheduledTime: now.Add(in)}}
+	}
+
+	passages := []api.TripPassage{
+		passage(100, -10*time.Minute),
+		passage(200, -30*time.Second),
+		passage(300, 5*time.Minute),
+		passage(400, 10*time.Minute),
+	}
+
+	tests := []struct {
+		from int
+		want []int
+	}{
+		{0, []int{200, 300, 400}},
+		{300, []int{300, 400}},
+		{999, []int{200, 300, 400}},
+	}
+
+	for _, tt := range tests {
+		got := remainingPassages(passages, tt.from, now)
+
+		var stops []int
+		for _, p := range got {
+			stops = append(stops, p.Stop)
+		}
+
+		if !slices.Equal(stops, tt.want) {
+			t.Errorf("from %d: stops = %v, want %v", tt.from, stops, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/schema"
+)
+
+// watchInterval is how often --watch refreshes while the API keeps up.
+const watchInterval = 30 * time.Second
+
+// watchCache holds the departures of the last successful refresh, so a failed
+// refresh shows them as stale instead of nothing.
+type watchCache struct {
+	departures []api.Departure
+	updated    time.Time // zero until a refresh succeeds
+}
+
+// current returns the cached departures that have not left by now; their
+// countdowns are computed from the cached times.
+func (w *watchCache) current(now time.Time) []api.Departure {
+	departures := make([]api.Departure, 0, len(w.departures))
+
+	for _, d := range w.departures {
+		if d.ExpectedTime().Add(passedSlack).After(now) {
+			departures = append(departures, d)
+		}
+	}
+
+	return departures
+}
+
+// snapshotStream writes watch snapshots as newline-delimited JSON.
+type snapshotStream struct {
+	w           io.Writer
+	changesOnly bool
+	last        []byte // departures and error of the previous snapshot
+	emitted     bool
+}
+
+func newSnapshotStream(w io.Writer, changesOnly bool) *snapshotStream {
+	return &snapshotStream{w: w, changesOnly: changesOnly}
+}
+
+// Emit writes snap, unless changesOnly is set and its departures and error
+// match the previous snapshot. The timestamp is not part of the comparison.
+func (s *snapshotStream) Emit(snap schema.Snapshot) error {
+	if snap.Departures == nil {
+		snap.Departures = []schema.Departure{}
+	}
+
+	content, err := json.Marshal(struct {
+		Departures []schema.Departure `json:"departures"`
+		Error      string             `json:"error,omitempty"`
+	}{snap.Departures, snap.Error})
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	if s.changesOnly && s.emitted && bytes.Equal(content, s.last) {
+		return nil
+	}
+
+	line, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+
+	if _, err := s.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+
+	s.last = content
+	s.emitted = true
+
+	return nil
+}
@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/output"
+	"github.com/dedene/delijn-cli/internal/tui"
+)
+
+// liveWatch is the state of an interactive `departures --watch`.
+type liveWatch struct {
+	watchCache // every departure of the last successful refresh
+
+	delays     map[string]int // delays of the last refresh, by departure key
+	prevDelays map[string]int // delays of the refresh before
+	err        error
+	next       time.Time
+	loading    bool
+	line       string // line filter; empty shows every line
+}
+
+type liveResult struct {
+	departures []api.Departure
+	err        error
+}
+
+// runLiveWatch redraws the departures in place until q or Ctrl+C. Keys: r
+// refreshes now, + and - change --count and l cycles through the lines. A
+// failed refresh keeps the last departures on screen, marked stale, and
+// refreshes back off while the API is throttling.
+func (c *DeparturesCmd) runLiveWatch(client *api.Client, stops []int, root *RootFlags, interval time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	t, err := tui.Open(os.Stdin, os.Stdout, true)
+	if err != nil {
+		return err
+	}
+
+	defer t.Close()
+
+	// The line filter applies when drawing, so l can cycle through every
+	// line without fetching again.
+	w := &liveWatch{line: c.Line}
+	c.Line = ""
+
+	keys := t.Keys()
+	results := make(chan liveResult, 1)
+	backoff := api.NewBackoff(interval)
+
+	refresh := func() {
+		if w.loading {
+			return
+		}
+
+		w.loading = true
+		w.next = time.Now().Add(interval)
+
+		go func() {
+			fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			defer cancel()
+
+			deps, err := c.collectDepartures(fetchCtx, client, stops)
+
+			select {
+			case results <- liveResult{departures: deps, err: err}:
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	refresh()
+
+	for {
+		width, height := t.Size()
+
+		if err := t.Draw(c.liveFrame(ctx, client, stops, root, w, width, height)); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case k, ok := <-keys:
+			if !ok {
+				return nil
+			}
+
+			switch k {
+			case "q", tui.KeyCtrlC:
+				return nil
+			case "r":
+				refresh()
+			case "+", "=":
+				c.Count++
+			case "-":
+				c.Count = max(c.Count-1, 1)
+			case "l":
+				w.line = nextLine(w.departures, w.line)
+			}
+		case r := <-results:
+			w.loading = false
+			w.err = r.err
+			w.next = time.Now().Add(backoff.Next(r.err))
+
+			if r.err == nil {
+				w.departures = r.departures
+				w.prevDelays = w.delays
+				w.delays = make(map[string]int, len(r.departures))
+
+				for _, d := range r.departures {
+					w.delays[d.Key()] = d.DelaySeconds()
+				}
+
+				w.updated = time.Now()
+			}
+		case now := <-ticker.C:
+			if !now.Before(w.next) {
+				refresh()
+			}
+		}
+	}
+}
+
+// liveFrame renders the table and a status footer as screen lines.
+func (c *DeparturesCmd) liveFrame(ctx context.Context, client *api.Client, stops []int, root *RootFlags, w *liveWatch, width, height int) []string {
+	var departures []api.Departure
+
+	for _, d := range w.current(time.Now()) {
+		if w.line == "" || matchesLine(d, w.line) {
+			departures = append(departures, d)
+		}
+	}
+
+	departures = c.limit(departures)
+
+	l := c.listing(ctx, client, stops, departures, root)
+	if c.GroupBy == "" {
+		markDelayChanges(l, departures, w.prevDelays)
+	}
+
+	var buf bytes.Buffer
+
+	if err := root.renderTo(&buf, l); err != nil {
+		fmt.Fprintf(&buf, "Error: %v\n", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+
+	if w.err != nil {
+		lines = append(lines, "", output.Red("Error: "+w.err.Error()))
+	}
+
+	// Keep the footer on screen when the table is taller than the terminal.
+	if room := height - 2; len(lines) > room {
+		lines = lines[:max(room, 0)]
+	}
+
+	now := time.Now()
+
+	status := "loading"
+
+	switch {
+	case w.updated.IsZero():
+	case w.err != nil:
+		status = "stale since " + output.FormatTimeWithSeconds(w.updated)
+	default:
+		status = fmt.Sprintf("updated %ds ago", int(now.Sub(w.updated).Seconds()))
+	}
+
+	line := w.line
+	if line == "" {
+		line = "all"
+	}
+
+	footer := fmt.Sprintf("%s · next refresh in %ds · count %d · line %s    q quit · r refresh · +/- count · l line",
+		status, max(int(time.Until(w.next).Round(time.Second).Seconds()), 0), c.Count, line)
+
+	if n := len([]rune(footer)); n > width {
+		footer = string([]rune(footer)[:width])
+	}
+
+	return append(lines, "", output.Dim(footer))
+}
+
+// markDelayChanges flags the delay of departures whose delay changed since
+// the previous refresh, with an arrow for later or earlier.
+func markDelayChanges(l output.Listing, departures []api.Departure, prev map[string]int) {
+	if prev == nil {
+		return
+	}
+
+	for i, d := range departures {
+		before, ok := prev[d.Key()]
+		if !ok || before == d.DelaySeconds() {
+			continue
+		}
+
+		mark := output.Red(" ▲")
+		if d.DelaySeconds() < before {
+			mark = output.Green(" ▼")
+		}
+
+		l.Records[i].Styled["delay"] += mark
+	}
+}
+
+// nextLine returns the line after current in the lines of departures, or ""
+// (all lines) after the last one.
+func nextLine(departures []api.Departure, current string) string {
+	var lines []string
+
+	seen := map[string]bool{}
+
+	for _, d := range departures {
+		if line := formatLineNumber(d); !seen[line] {
+			seen[line] = true
+			lines = append(lines, line)
+		}
+	}
+
+	// Numeric order for numbers, e.g. 2 before 10.
+	sort.Slice(lines, func(i, j int) bool {
+		if len(lines[i]) != len(lines[j]) {
+			return len(lines[i]) < len(lines[j])
+		}
+
+		return lines[i] < lines[j]
+	})
+
+	if current == "" {
+		if len(lines) == 0 {
+			return ""
+		}
+
+		return lines[0]
+	}
+
+	for i, line := range lines {
+		if line == current && i+1 < len(lines) {
+			return lines[i+1]
+		}
+	}
+
+	return ""
+}
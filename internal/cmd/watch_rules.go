@@ -0,0 +1,489 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/config"
+	"github.com/dedene/delijn-cli/internal/filter"
+	"github.com/dedene/delijn-cli/internal/output"
+	"github.com/dedene/delijn-cli/internal/schema"
+)
+
+const (
+	// rulesFetch is how many departures per stop the rules are checked against.
+	rulesFetch = 20
+
+	// hookTimeout bounds one hook run.
+	hookTimeout = 30 * time.Second
+
+	// maxRunningHooks bounds the hooks that run at once.
+	maxRunningHooks = 4
+)
+
+type WatchRulesCmd struct {
+	Interval time.Duration `help:"Polling interval" default:"30s"`
+	DryRun   bool          `help:"Report matches without running the hooks"`
+}
+
+func (c *WatchRulesCmd) Run(root *RootFlags) error {
+	output.SetNoColor(root.NoColor)
+
+	if c.Interval < 10*time.Second {
+		return fmt.Errorf("interval must be at least 10s, got %s", c.Interval)
+	}
+
+	rules, err := loadRules()
+	if err != nil {
+		return err
+	}
+
+	client, err := root.newClient()
+	if err != nil {
+		return err
+	}
+
+	resolveCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	stops, err := resolveRules(resolveCtx, client, rules)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "Watching %d rules at %d stops every %s (Ctrl+C to stop)\n", len(rules), len(stops), c.Interval)
+
+	hooks := newHookRunner()
+	defer hooks.wait()
+
+	return c.watch(ctx, root, client, newRuleWatcher(rules), hooks, stops)
+}
+
+// loadRules parses the hooks of the user config and profile. Hooks run
+// commands, so those of a shared delijn.yaml are never loaded. Exec hooks
+// run in the directory of the user config.
+func loadRules() ([]*rule, error) {
+	cfg, err := config.ReadTrustedConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := config.ConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Hooks) == 0 {
+		return nil, errors.New("no hooks configured; add them under hooks: with 'delijn config edit'")
+	}
+
+	rules := make([]*rule, 0, len(cfg.Hooks))
+
+	for i, h := range cfg.Hooks {
+		r, err := parseRule(h)
+		if err != nil {
+			return nil, &ExitError{Code: 2, Err: fmt.Errorf("hooks[%d]: %w", i, err)}
+		}
+
+		r.dir = filepath.Dir(path)
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
+
+// watch refreshes stops every --interval, backing off while the API is
+// throttled, and starts the hooks whose rules start to match.
+func (c *WatchRulesCmd) watch(ctx context.Context, root *RootFlags, client *api.Client, w *ruleWatcher, hooks *hookRunner, stops []int) error {
+	fetcher := &DeparturesCmd{Count: rulesFetch}
+	backoff := api.NewBackoff(c.Interval)
+	enc := json.NewEncoder(os.Stdout)
+
+	for {
+		var fetchErr error
+
+		for _, stop := range stops {
+			fetchCtx, cancelFetch := context.WithTimeout(ctx, 30*time.Second)
+			departures, err := fetcher.fetchDepartures(fetchCtx, client, []int{stop})
+
+			cancelFetch()
+
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+
+				fmt.Fprintf(os.Stderr, "Warning: stop %d: %v\n", stop, err)
+				fetchErr = err
+
+				continue
+			}
+
+			for _, m := range w.check(stop, departures, time.Now()) {
+				payload := schema.NewHook(m.rule.hook.On, m.event)
+
+				if root.JSON || root.Format == output.FormatNDJSON {
+					if err := enc.Encode(payload); err != nil {
+						return fmt.Errorf("write match: %w", err)
+					}
+				} else {
+					outputRuleMatch(m)
+				}
+
+				if c.DryRun {
+					continue
+				}
+
+				hooks.start(ctx, m, payload)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff.Next(fetchErr)):
+		}
+	}
+}
+
+// hookRunner runs hooks in the background, so a slow hook does not hold up
+// the next refresh.
+type hookRunner struct {
+	slots   chan struct{}
+	running sync.WaitGroup
+}
+
+func newHookRunner() *hookRunner {
+	return &hookRunner{slots: make(chan struct{}, maxRunningHooks)}
+}
+
+// start runs the hook of m. When maxRunningHooks hooks are still running,
+// the match is dropped with a warning rather than queued.
+func (h *hookRunner) start(ctx context.Context, m ruleMatch, payload schema.Hook) {
+	select {
+	case h.slots <- struct{}{}:
+	default:
+		fmt.Fprintf(os.Stderr, "Warning: %d hooks still running; skipping %q\n", maxRunningHooks, m.rule.hook.On)
+
+		return
+	}
+
+	h.running.Add(1)
+
+	go func() {
+		defer func() {
+			<-h.slots
+			h.running.Done()
+		}()
+
+		if err := m.rule.run(ctx, m.event, payload); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}()
+}
+
+// wait blocks until the running hooks are done.
+func (h *hookRunner) wait() {
+	h.running.Wait()
+}
+
+func outputRuleMatch(m ruleMatch) {
+	d := *m.event.Departure
+	action := "exec " + m.rule.hook.Exec
+
+	if m.rule.hook.Post != "" {
+		action = "post " + m.rule.hook.Post
+	}
+
+	fmt.Fprintf(os.Stdout, "%s  %s  %s %s (%s)  %s\n",
+		output.Dim(output.FormatTimeWithSeconds(m.event.Time)),
+		m.rule.hook.On,
+		output.Bold(formatLineNumber(d)),
+		d.Destination,
+		output.FormatTime(d.ExpectedTime()),
+		output.Dim("-> "+action),
+	)
+}
+
+// hookFilterFields are the fields of a rule condition: those of --where,
+// plus the change that came with the refresh.
+var hookFilterFields = func() filter.Fields {
+	fields := maps.Clone(departureFilterFields)
+	fields["event"] = filter.String
+	fields["change"] = filter.Number
+
+	return fields
+}()
+
+// rule is a parsed hook: `<condition> [at <stop>] [line <line>]`.
+type rule struct {
+	hook  config.Hook
+	where *filter.Filter
+	stop  string // stop reference; empty for every favorite
+	line  string
+	stops []int  // resolved stop numbers
+	dir   string // working directory of an exec hook; "" is the current one
+}
+
+func parseRule(h config.Hook) (*rule, error) {
+	cond, stop, line := splitRule(h.On)
+	if cond == "" {
+		return nil, fmt.Errorf("rule %q has no condition", h.On)
+	}
+
+	where, err := filter.Compile(cond, hookFilterFields)
+	if err != nil {
+		return nil, fmt.Errorf("rule %q: %w", h.On, err)
+	}
+
+	return &rule{hook: h, where: where, stop: stop, line: line}, nil
+}
+
+// splitRule splits a rule into its condition, the stop after "at" and the
+// line after "line". The clauses trail the condition in either order. A
+// "line" followed by more than a line number, as in line == "1", belongs to
+// the condition.
+func splitRule(src string) (cond, stop, line string) {
+	for {
+		at, l := lastWord(src, "at"), lastWord(src, "line")
+
+		switch {
+		case l > at && line == "" && lineNumberPattern.MatchString(strings.TrimSpace(src[l+len("line"):])):
+			src, line = src[:l], strings.TrimSpace(src[l+len("line"):])
+		case at > l && stop == "":
+			src, stop = src[:at], strings.TrimSpace(src[at+len("at"):])
+		default:
+			return strings.TrimSpace(src), stop, line
+		}
+	}
+}
+
+// lineNumberPattern matches the line number of a rule's "line" clause.
+var lineNumberPattern = regexp.MustCompile(`^[0-9A-Za-z]+$`)
+
+// lastWord returns the index of the last occurrence of word in s that stands
+// on its own outside quotes, or -1.
+func lastWord(s, word string) int {
+	idx := -1
+
+	var quote byte
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case strings.HasPrefix(s[i:], word) &&
+			(i == 0 || s[i-1] == ' ') &&
+			(i+len(word) == len(s) || s[i+len(word)] == ' '):
+			idx = i
+		}
+	}
+
+	return idx
+}
+
+// resolveRules resolves the stop of each rule and returns every stop to
+// watch.
+func resolveRules(ctx context.Context, client *api.Client, rules []*rule) ([]int, error) {
+	var stops []int
+
+	for _, r := range rules {
+		var refs []string
+		if r.stop != "" {
+			refs = []string{r.stop}
+		}
+
+		named, err := namedStops(ctx, client, refs)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", r.hook.On, err)
+		}
+
+		for _, s := range named {
+			r.stops = append(r.stops, s.stop)
+
+			if !slices.Contains(stops, s.stop) {
+				stops = append(stops, s.stop)
+			}
+		}
+	}
+
+	return stops, nil
+}
+
+// ruleMatch is a rule that started to match a departure.
+type ruleMatch struct {
+	rule  *rule
+	event api.Event
+}
+
+// ruleWatcher checks rules against successive refreshes of their stops. A
+// rule on the state of a departure fires when it starts to match: delay >
+// 300 fires once when the delay passes 5 minutes, not on every refresh
+// after that. A rule that only matches because of the change in this
+// refresh, like event == "delay_changed", fires on every such change.
+type ruleWatcher struct {
+	rules   []*rule
+	prev    map[int][]api.Departure           // last refresh of each stop
+	matched map[*rule]map[int]map[string]bool // rule -> stop -> departure keys
+}
+
+func newRuleWatcher(rules []*rule) *ruleWatcher {
+	return &ruleWatcher{
+		rules:   rules,
+		prev:    map[int][]api.Departure{},
+		matched: map[*rule]map[int]map[string]bool{},
+	}
+}
+
+// check returns the rules that start to match a departure in this refresh
+// of stop. The changes since the previous refresh come as events; the
+// first refresh has none.
+func (w *ruleWatcher) check(stop int, departures []api.Departure, now time.Time) []ruleMatch {
+	var events []api.Event
+
+	if prev, ok := w.prev[stop]; ok {
+		events = api.DiffDepartures(stop, prev, departures, now)
+	}
+
+	w.prev[stop] = departures
+
+	changed := make(map[string]bool, len(events))
+	for _, e := range events {
+		changed[e.Departure.Key()] = true
+	}
+
+	for _, d := range departures {
+		if !changed[d.Key()] {
+			events = append(events, api.Event{Time: now, Stop: stop, Departure: &d})
+		}
+	}
+
+	var matches []ruleMatch
+
+	for _, r := range w.rules {
+		if !slices.Contains(r.stops, stop) {
+			continue
+		}
+
+		if w.matched[r] == nil {
+			w.matched[r] = map[int]map[string]bool{}
+		}
+
+		was := w.matched[r][stop]
+		matching := map[string]bool{}
+
+		for _, e := range events {
+			if !r.matches(e) {
+				continue
+			}
+
+			key := e.Departure.Key()
+			matching[key] = true
+
+			if !was[key] || !r.matches(api.Event{Time: e.Time, Stop: e.Stop, Departure: e.Departure}) {
+				matches = append(matches, ruleMatch{rule: r, event: e})
+			}
+		}
+
+		w.matched[r][stop] = matching
+	}
+
+	return matches
+}
+
+// matches reports whether the departure of e satisfies r.
+func (r *rule) matches(e api.Event) bool {
+	d := *e.Departure
+	if r.line != "" && !matchesLine(d, r.line) {
+		return false
+	}
+
+	record := departureFilterRecord(d, e.Time)
+	record["event"] = string(e.Type)
+	record["change"] = float64(e.Change)
+
+	return r.where.Match(record)
+}
+
+// run executes the hook of r for a match.
+func (r *rule) run(ctx context.Context, e api.Event, payload schema.Hook) error {
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout)
+	defer cancel()
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode hook payload: %w", err)
+	}
+
+	if r.hook.Post != "" {
+		return postHook(ctx, r.hook.Post, body)
+	}
+
+	d := *e.Departure
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", r.hook.Exec) //nolint:gosec // user-configured hook
+	cmd.Dir = r.dir
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	cmd.Env = append(os.Environ(),
+		"DELIJN_RULE="+r.hook.On,
+		"DELIJN_EVENT="+string(e.Type),
+		"DELIJN_STOP="+strconv.Itoa(e.Stop),
+		"DELIJN_LINE="+formatLineNumber(d),
+		"DELIJN_DESTINATION="+d.Destination,
+		"DELIJN_DEPARTURE="+output.FormatTime(d.ExpectedTime()),
+		"DELIJN_DELAY="+strconv.Itoa(d.DelaySeconds()),
+	)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run hook %q: %w", r.hook.Exec, err)
+	}
+
+	return nil
+}
+
+func postHook(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create hook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", api.UserAgent)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("post hook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("post hook %s: %s", url, resp.Status)
+	}
+
+	return nil
+}
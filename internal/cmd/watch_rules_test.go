@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/config"
+	"github.com/dedene/delijn-cli/internal/schema"
+)
+
+func TestSplitRule(t *testing.T) {
+	tests := []struct {
+		src              string
+		cond, stop, line string
+	}{
+		{"delay > 300 at @home line 1", "delay > 300", "@home", "1"},
+		{"delay > 300 at @home", "delay > 300", "@home", ""},
+		{"delay > 300 line 1", "delay > 300", "", "1"},
+		{"delay > 300 line 1 at @home", "delay > 300", "@home", "1"},
+		{`line == "1" line 1`, `line == "1"`, "", "1"},
+		{`event == "cancelled" at gent korenmarkt line 4`, `event == "cancelled"`, "gent korenmarkt", "4"},
+		{`dest ~ "at home" && line == "1"`, `dest ~ "at home" && line == "1"`, "", ""},
+		{"status == 'cancelled'", "status == 'cancelled'", "", ""},
+	}
+
+	for _, tt := range tests {
+		cond, stop, line := splitRule(tt.src)
+		if cond != tt.cond || stop != tt.stop || line != tt.line {
+			t.Errorf("splitRule(%q) = %q, %q, %q; want %q, %q, %q", tt.src, cond, stop, line, tt.cond, tt.stop, tt.line)
+		}
+	}
+
+	for _, on := range []string{"delay > 300 line 1", "delay > 300 line 1 at @home"} {
+		if _, err := parseRule(config.Hook{On: on}); err != nil {
+			t.Errorf("parseRule(%q) error: %v", on, err)
+		}
+	}
+
+	if _, err := parseRule(config.Hook{On: "at @home"}); err == nil {
+		t.Error("parseRule() accepted a rule without a condition")
+	}
+
+	if _, err := parseRule(config.Hook{On: "speed > 3 at @home"}); err == nil {
+		t.Error("parseRule() accepted an unknown field")
+	}
+}
+
+func TestRuleWatcherFiresOnMatchStart(t *testing.T) {
+	now := time.Date(2026, 10, 18, 8, 0, 0, 0, time.Local)
+	departure := func(line int, late time.Duration) api.Departure {
+		scheduled := now.Add(10 * time.Minute)
+		expected := scheduled.Add(late)
+
+		return api.Departure{
+			LineNumber:       line,
+			ScheduledTime:    scheduled,
+			ScheduledTimeRaw: scheduled.Format("2006-01-02T15:04:05"),
+			RealTime:         &expected,
+			PredictionStatus: []api.PredictionStatus{api.StatusRealtime},
+		}
+	}
+
+	late, err := parseRule(config.Hook{On: "delay > 300 at @home line 1", Exec: "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := parseRule(config.Hook{On: `event == "delay_changed"`, Exec: "true"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	late.stops, changed.stops = []int{200144}, []int{200144}
+	w := newRuleWatcher([]*rule{late, changed})
+
+	steps := []struct {
+		departures []api.Departure
+		want       []string // rules that fire
+	}{
+		{[]api.Departure{departure(1, 6*time.Minute), departure(2, 8*time.Minute)}, []string{late.hook.On}},
+		{[]api.Departure{departure(1, 7*time.Minute), departure(2, 8*time.Minute)}, []string{changed.hook.On}},
+		{[]api.Departure{departure(1, 2*time.Minute)}, []string{changed.hook.On}},
+		{[]api.Departure{departure(1, 6*time.Minute)}, []string{late.hook.On, changed.hook.On}},
+	}
+
+	for i, step := range steps {
+		var got []string
+		for _, m := range w.check(200144, step.departures, now) {
+			got = append(got, m.rule.hook.On)
+		}
+
+		if strings.Join(got, "|") != strings.Join(step.want, "|") {
+			t.Errorf("refresh %d: fired %q, want %q", i, got, step.want)
+		}
+	}
+
+	if got := w.check(100001, []api.Departure{departure(1, 9*time.Minute)}, now); len(got) != 0 {
+		t.Errorf("rules fired at a stop they do not watch: %+v", got)
+	}
+}
+
+func TestRuleRun(t *testing.T) {
+	expected := time.Date(2026, 10, 18, 8, 7, 0, 0, time.Local)
+	d := api.Departure{LineNumber: 1, Destination: "Flanders Expo", ScheduledTime: expected.Add(-6 * time.Minute), RealTime: &expected}
+	e := api.Event{Type: api.EventDelayChanged, Time: expected, Stop: 200144, Departure: &d, Change: 120}
+	payload := schema.NewHook("delay > 300", e)
+
+	// A relative path resolves against the directory of the config.
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	r := &rule{hook: config.Hook{On: "delay > 300", Exec: `{ echo "$DELIJN_EVENT $DELIJN_STOP $DELIJN_DELAY"; cat; } > out`}, dir: dir}
+
+	if err := r.run(context.Background(), e, payload); err != nil {
+		t.Fatalf("run() exec error: %v", err)
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env, stdin, _ := strings.Cut(string(got), "\n")
+	if env != "delay_changed 200144 360" {
+		t.Errorf("hook env = %q", env)
+	}
+
+	var sent schema.Hook
+	if err := json.Unmarshal([]byte(stdin), &sent); err != nil || sent.Rule != "delay > 300" || sent.DelayChangeSeconds != 120 {
+		t.Errorf("hook stdin = %s (%v)", stdin, err)
+	}
+
+	var posted []byte
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		posted, _ = io.ReadAll(req.Body)
+
+		if req.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+		}
+	}))
+	defer srv.Close()
+
+	r.hook = config.Hook{On: "delay > 300", Post: srv.URL}
+	if err := r.run(context.Background(), e, payload); err != nil {
+		t.Fatalf("run() post error: %v", err)
+	}
+
+	if !strings.Contains(string(posted), `"event":"delay_changed"`) {
+		t.Errorf("posted %s", posted)
+	}
+
+	r.hook.Post = srv.URL + "/missing"
+	srv.Config.Handler = http.NotFoundHandler()
+
+	if err := r.run(context.Background(), e, payload); err == nil {
+		t.Error("run() ignored a 404 from the hook URL")
+	}
+}
+
+func TestLoadRulesIgnoresSharedHooks(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+
+	config.SetConfigPath(filepath.Join(root, "user.yaml"))
+	t.Cleanup(func() { config.SetConfigPath("") })
+
+	shared := "hooks:\n  - on: delay > 0\n    exec: touch pwned\n"
+	if err := os.WriteFile(filepath.Join(root, config.SharedConfigName), []byte(shared), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := loadRules(); err == nil {
+		t.Fatal("loadRules() loaded hooks from the shared config")
+	}
+
+	user := "hooks:\n  - on: delay > 300\n    post: https://example.com/hook\n"
+	if err := os.WriteFile(filepath.Join(root, "user.yaml"), []byte(user), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := loadRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rules) != 1 || rules[0].hook.On != "delay > 300" {
+		t.Errorf("rules = %+v, want only the user config's", rules)
+	}
+}
+
+func TestHookRunnerDoesNotBlock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	h := newHookRunner()
+
+	d := api.Departure{LineNumber: 1}
+	e := api.Event{Stop: 200144, Departure: &d}
+	m := ruleMatch{rule: &rule{hook: config.Hook{On: "delay > 0", Exec: "sleep 10"}}, event: e}
+
+	start := time.Now()
+
+	for range maxRunningHooks + 1 {
+		h.start(ctx, m, schema.NewHook(m.rule.hook.On, e))
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("start() blocked for %s", elapsed)
+	}
+
+	if n := len(h.slots); n != maxRunningHooks {
+		t.Errorf("%d hooks running, want %d", n, maxRunningHooks)
+	}
+
+	cancel()
+	h.wait()
+
+	if n := len(h.slots); n != 0 {
+		t.Errorf("%d hooks still running after wait()", n)
+	}
+}
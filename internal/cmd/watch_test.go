@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/schema"
+)
+
+func TestSnapshotStreamNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	stream := newSnapshotStream(&buf, false)
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	if err := stream.Emit(schema.Snapshot{Timestamp: now, Stop: 200552}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stream.Emit(schema.Snapshot{Timestamp: now, Stop: 200552, Error: "get departures: timeout"}); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+
+	var first map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("line is not JSON: %v", err)
+	}
+
+	if deps, ok := first["departures"].([]any); !ok || len(deps) != 0 {
+		t.Errorf("departures = %v, want empty array", first["departures"])
+	}
+
+	if _, ok := first["error"]; ok {
+		t.Error("error should be omitted when the fetch succeeded")
+	}
+
+	if !strings.Contains(lines[1], `"error":"get departures: timeout"`) {
+		t.Errorf("second line should carry the error, got %s", lines[1])
+	}
+}
+
+func TestSnapshotStreamChangesOnly(t *testing.T) {
+	var buf bytes.Buffer
+
+	stream := newSnapshotStream(&buf, true)
+	deps := []schema.Departure{{LineNumber: 1, Destination: "Flanders Expo"}}
+	changed := []schema.Departure{{LineNumber: 1, Destination: "Flanders Expo", DelaySeconds: 60, Realtime: true}}
+
+	snaps := []schema.Snapshot{
+		{Timestamp: time.Now(), Stop: 200552, Departures: deps},
+		{Timestamp: time.Now().Add(time.Minute), Stop: 200552, Departures: deps},
+		{Timestamp: time.Now().Add(2 * time.Minute), Stop: 200552, Departures: changed},
+	}
+
+	for _, snap := range snaps {
+		if err := stream.Emit(snap); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := strings.Count(buf.String(), "\n"); got != 2 {
+		t.Errorf("emitted %d snapshots, want 2 (unchanged refresh skipped)", got)
+	}
+}
+
+func TestNextLine(t *testing.T) {
+	deps := []api.Departure{
+		{LineNumber: 10}, {LineNumber: 2}, {LineNumber: 10}, {LineNumber: 1, LinePublicNumber: "N1"},
+	}
+
+	var got []string
+
+	line := ""
+	for range 4 {
+		line = nextLine(deps, line)
+		got = append(got, line)
+	}
+
+	want := []string{"2", "10", "N1", ""}
+	if !slices.Equal(got, want) {
+		t.Errorf("cycling lines = %q, want %q", got, want)
+	}
+}
+
+func TestMarkDelayChanges(t *testing.T) {
+	scheduled := time.Now()
+	late := scheduled.Add(3 * time.Minute)
+	deps := []api.Departure{
+		{LineNumber: 1, ScheduledTimeRaw: "a", ScheduledTime: scheduled, RealTime: &late},
+		{LineNumber: 2, ScheduledTimeRaw: "b", ScheduledTime: scheduled},
+	}
+
+	prev := map[string]int{deps[0].Key(): 60, deps[1].Key(): 0}
+
+	l := departuresListing([]int{200552}, deps, false)
+	markDelayChanges(l, deps, prev)
+
+	if got := l.Records[0].Styled["delay"]; !strings.Contains(got, "▲") {
+		t.Errorf("delay that grew = %q, want it marked", got)
+	}
+
+	if got := l.Records[1].Styled["delay"]; strings.ContainsAny(got, "▲▼") {
+		t.Errorf("unchanged delay = %q, want it unmarked", got)
+	}
+}
+
+func TestWatchSnapshotStale(t *testing.T) {
+	now := time.Now()
+	updated := now.Add(-2 * time.Minute)
+	c := &DeparturesCmd{Count: 10}
+	cache := &watchCache{
+		departures: []api.Departure{
+			{LineNumber: 1, ScheduledTime: now.Add(-3 * time.Minute)},
+			{LineNumber: 2, ScheduledTime: now.Add(-30 * time.Second)},
+			{LineNumber: 3, ScheduledTime: now.Add(4 * time.Minute)},
+		},
+		updated: updated,
+	}
+
+	snap := c.snapshot([]int{200144}, cache, &api.CircuitBreakerError{}, now)
+
+	if snap.StaleSince == nil || !snap.StaleSince.Equal(updated) {
+		t.Errorf("stale_since = %v, want %v", snap.StaleSince, updated)
+	}
+
+	if snap.Error == "" {
+		t.Error("error is empty")
+	}
+
+	var lines []int
+	for _, d := range snap.Departures {
+		lines = append(lines, d.LineNumber)
+	}
+
+	// The departure that left three minutes ago is dropped.
+	if !slices.Equal(lines, []int{2, 3}) {
+		t.Errorf("lines = %v, want [2 3]", lines)
+	}
+
+	if snap := c.snapshot([]int{200144}, cache, nil, now); snap.StaleSince != nil || snap.Error != "" {
+		t.Errorf("successful refresh: stale_since = %v, error = %q", snap.StaleSince, snap.Error)
+	}
+}
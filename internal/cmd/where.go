@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/filter"
+	"github.com/dedene/delijn-cli/internal/output"
+)
+
+// compileWhere compiles a --where expression. An empty expression yields a
+// nil filter, which matches everything. Syntax errors exit with the usage
+// code, like flag parse errors.
+func compileWhere(expr string, fields filter.Fields) (*filter.Filter, error) {
+	if expr == "" {
+		return nil, nil
+	}
+
+	f, err := filter.Compile(expr, fields)
+	if err != nil {
+		return nil, &ExitError{Code: 2, Err: fmt.Errorf("--where: %w", err)}
+	}
+
+	return f, nil
+}
+
+var departureFilterFields = filter.Fields{
+	"stop":        filter.Number,
+	"line":        filter.String,
+	"line_number": filter.Number,
+	"dest":        filter.String,
+	"destination": filter.String,
+	"direction":   filter.String,
+	"type":        filter.String,
+	"delay":       filter.Number,
+	"minutes":     filter.Number,
+	"time":        filter.String,
+	"scheduled":   filter.String,
+	"status":      filter.String,
+	"realtime":    filter.Bool,
+}
+
+func departureFilterRecord(d api.Departure, now time.Time) filter.Record {
+	return filter.Record{
+		"stop":        float64(d.StopNumber),
+		"line":        formatLineNumber(d),
+		"line_number": float64(d.LineNumber),
+		"dest":        d.Destination,
+		"destination": d.Destination,
+		"direction":   d.Direction,
+		"type":        d.TransportType,
+		"delay":       float64(d.DelaySeconds()),
+		"minutes":     math.Floor(d.ExpectedTime().Sub(now).Minutes()),
+		"time":        output.FormatTime(d.ExpectedTime()),
+		"scheduled":   output.FormatTime(d.ScheduledTime),
+		"status":      d.Status(),
+		"realtime":    d.IsRealTime(),
+	}
+}
+
+var stopFilterFields = filter.Fields{
+	"number":       filter.Number,
+	"name":         filter.String,
+	"municipality": filter.String,
+	"entity":       filter.Number,
+}
+
+func stopFilterRecord(s api.Stop) filter.Record {
+	return filter.Record{
+		"number":       float64(s.Number),
+		"name":         s.Description,
+		"municipality": s.Municipality,
+		"entity":       float64(s.EntityNumber),
+	}
+}
+
+var lineFilterFields = filter.Fields{
+	"line":        filter.String,
+	"number":      filter.Number,
+	"entity":      filter.Number,
+	"type":        filter.String,
+	"description": filter.String,
+}
+
+func lineFilterRecord(l api.Line) filter.Record {
+	line := l.PublicNumber
+	if line == "" {
+		line = strconv.Itoa(l.LineNumber)
+	}
+
+	return filter.Record{
+		"line":        line,
+		"number":      float64(l.LineNumber),
+		"entity":      float64(l.EntityNumber),
+		"type":        l.TransportType,
+		"description": l.Description,
+	}
+}
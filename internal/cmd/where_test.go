@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/filter"
+)
+
+func TestFilterRecordsMatchFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields filter.Fields
+		record filter.Record
+	}{
+		{"departures", departureFilterFields, departureFilterRecord(api.Departure{}, time.Now())},
+		{"stops", stopFilterFields, stopFilterRecord(api.Stop{})},
+		{"lines", lineFilterFields, lineFilterRecord(api.Line{})},
+	}
+
+	for _, tt := range tests {
+		for name, kind := range tt.fields {
+			v, ok := tt.record[name]
+			if !ok {
+				t.Errorf("%s: field %q has no value", tt.name, name)
+
+				continue
+			}
+
+			var gotKind filter.Kind
+
+			switch v.(type) {
+			case float64:
+				gotKind = filter.Number
+			case bool:
+				gotKind = filter.Bool
+			case string:
+				gotKind = filter.String
+			default:
+				t.Errorf("%s: field %q has unsupported type %T", tt.name, name, v)
+			}
+
+			if gotKind != kind {
+				t.Errorf("%s: field %q is declared %s but holds a %s", tt.name, name, kind, gotKind)
+			}
+		}
+
+		if len(tt.record) != len(tt.fields) {
+			t.Errorf("%s: record has %d values for %d fields", tt.name, len(tt.record), len(tt.fields))
+		}
+	}
+}
+
+func TestDeparturesWhere(t *testing.T) {
+	f, err := compileWhere(`line in ("1", "2") && delay > 120 && dest ~ "expo"`, departureFilterFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	late := api.Departure{LineNumber: 1, Destination: "Flanders Expo",
+		ScheduledTimeRaw: "2026-03-01T08:10:00", RealTimeRaw: "2026-03-01T08:13:00",
+		PredictionStatus: []api.PredictionStatus{api.StatusRealtime}}
+	late.ParseTimes()
+
+	onTime := late
+	onTime.RealTimeRaw = "2026-03-01T08:10:00"
+	onTime.ParseTimes()
+
+	if !f.Match(departureFilterRecord(late, time.Now())) {
+		t.Error("late line 1 to Flanders Expo should match")
+	}
+
+	if f.Match(departureFilterRecord(onTime, time.Now())) {
+		t.Error("on-time departure should not match delay > 120")
+	}
+
+	if _, err := compileWhere(`delay >`, departureFilterFields); err == nil {
+		t.Error("incomplete expression should fail")
+	}
+}
@@ -1,18 +1,28 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
 
 	"gopkg.in/yaml.v3"
 )
 
 type File struct {
-	Favorites      map[string]int `yaml:"favorites,omitempty"`
-	DefaultStop    string         `yaml:"default_stop,omitempty"`
-	KeyringBackend string         `yaml:"keyring_backend,omitempty"`
-	WatchInterval  int            `yaml:"watch_interval,omitempty"`
-	Timezone       string         `yaml:"timezone,omitempty"`
+	Version        int                 `json:"version,omitempty"         yaml:"version,omitempty"`
+	Favorites      map[string]Favorite `json:"favorites,omitempty"       yaml:"favorites,omitempty"`
+	DefaultStop    string              `json:"default_stop,omitempty"    yaml:"default_stop,omitempty"`
+	KeyringBackend string              `json:"keyring_backend,omitempty" yaml:"keyring_backend,omitempty"`
+	WatchInterval  int                 `json:"watch_interval,omitempty"  yaml:"watch_interval,omitempty"`
+	Timezone       string              `json:"timezone,omitempty"        yaml:"timezone,omitempty"`
+	NotifyHook     string              `json:"notify_hook,omitempty"     yaml:"notify_hook,omitempty"`
+	Hooks          []Hook              `json:"hooks,omitempty"           yaml:"hooks,omitempty"`
+	Defaults       map[string]any      `json:"defaults,omitempty"        yaml:"defaults,omitempty"`
+	Profiles       map[string]File     `json:"profiles,omitempty"        yaml:"profiles,omitempty"`
 }
 
 func ConfigExists() (bool, error) {
@@ -32,12 +42,58 @@ func ConfigExists() (bool, error) {
 	return true, nil
 }
 
+// ReadConfig returns the effective configuration: the shared, user and
+// profile layers merged in that order.
 func ReadConfig() (File, error) {
+	layers, err := ReadLayers()
+	if err != nil {
+		return File{}, err
+	}
+
+	cfg, _ := Merge(layers)
+
+	return cfg, nil
+}
+
+// ReadTrustedConfig returns the user and profile layers merged, without the
+// shared delijn.yaml or its profiles. Settings that run commands, such as
+// notify_hook and hooks, are read from it.
+func ReadTrustedConfig() (File, error) {
+	layers, err := ReadLayers()
+	if err != nil {
+		return File{}, err
+	}
+
+	sharedPath, err := SharedConfigPath()
+	if err != nil {
+		return File{}, err
+	}
+
+	trusted := layers[:0]
+
+	for _, layer := range layers {
+		if sharedPath == "" || layer.Path != sharedPath {
+			trusted = append(trusted, layer)
+		}
+	}
+
+	cfg, _ := Merge(trusted)
+
+	return cfg, nil
+}
+
+// ReadUserConfig reads only the user config file. Use it for
+// read-modify-write cycles so values from other layers aren't copied in.
+func ReadUserConfig() (File, error) {
 	path, err := ConfigPath()
 	if err != nil {
 		return File{}, err
 	}
 
+	return readConfigFile(path)
+}
+
+func readConfigFile(path string) (File, error) {
 	b, err := os.ReadFile(path) //nolint:gosec // config file path
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -47,28 +103,118 @@ func ReadConfig() (File, error) {
 		return File{}, fmt.Errorf("read config: %w", err)
 	}
 
-	var cfg File
-	if err := yaml.Unmarshal(b, &cfg); err != nil {
+	cfg, warnings, err := ParseConfig(b)
+	if err != nil {
 		return File{}, fmt.Errorf("parse config %s: %w", path, err)
 	}
 
+	for _, w := range warnings {
+		warnOnce(path + ": " + w)
+	}
+
 	return cfg, nil
 }
 
+var unknownFieldRe = regexp.MustCompile(`field (\S+) not found in type`)
+
+// ParseConfig decodes config.yaml contents, migrating older schema versions
+// to CurrentVersion. Unknown keys don't fail decoding; they are returned as
+// warnings.
+func ParseConfig(b []byte) (File, []string, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return File{}, nil, fmt.Errorf("decode config yaml: %w", err)
+	}
+
+	if doc == nil {
+		return File{}, nil, nil
+	}
+
+	if _, err := migrate(doc); err != nil {
+		return File{}, nil, err
+	}
+
+	liftFlagDefaults(doc)
+
+	migrated, err := yaml.Marshal(doc)
+	if err != nil {
+		return File{}, nil, fmt.Errorf("encode migrated config: %w", err)
+	}
+
+	var cfg File
+
+	var warnings []string
+
+	dec := yaml.NewDecoder(bytes.NewReader(migrated))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(&cfg); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return File{}, nil, fmt.Errorf("decode config yaml: %w", err)
+		}
+
+		for _, msg := range typeErr.Errors {
+			m := unknownFieldRe.FindStringSubmatch(msg)
+			if m == nil {
+				return File{}, nil, fmt.Errorf("decode config yaml: %w", err)
+			}
+
+			warnings = append(warnings, fmt.Sprintf("unknown key %q ignored", m[1]))
+		}
+
+		cfg = File{}
+		if err := yaml.Unmarshal(migrated, &cfg); err != nil {
+			return File{}, nil, fmt.Errorf("decode config yaml: %w", err)
+		}
+	}
+
+	return cfg, warnings, nil
+}
+
+var (
+	warnedMu sync.Mutex
+	warned   = make(map[string]bool)
+)
+
+// warnOnce prints a config warning to stderr, once per process.
+func warnOnce(msg string) {
+	warnedMu.Lock()
+	defer warnedMu.Unlock()
+
+	if warned[msg] {
+		return
+	}
+
+	warned[msg] = true
+
+	fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
+}
+
+// WriteConfig writes cfg to the user config file at CurrentVersion,
+// keeping the previous file as config.yaml.bak.
 func WriteConfig(cfg File) error {
-	_, err := EnsureDir()
+	cfg.Version = CurrentVersion
+
+	b, err := yaml.Marshal(cfg)
 	if err != nil {
-		return fmt.Errorf("ensure config dir: %w", err)
+		return fmt.Errorf("encode config yaml: %w", err)
 	}
 
+	return WriteConfigBytes(b)
+}
+
+// WriteConfigBytes writes b, which must already be validated, to the user
+// config file as is, keeping its comments and key order. The previous file
+// is kept as config.yaml.bak.
+func WriteConfigBytes(b []byte) error {
 	path, err := ConfigPath()
 	if err != nil {
 		return err
 	}
 
-	b, err := yaml.Marshal(cfg)
-	if err != nil {
-		return fmt.Errorf("encode config yaml: %w", err)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("ensure config dir: %w", err)
 	}
 
 	tmp := path + ".tmp"
@@ -77,9 +223,37 @@ func WriteConfig(cfg File) error {
 		return fmt.Errorf("write config: %w", err)
 	}
 
+	if err := backupConfig(path); err != nil {
+		_ = os.Remove(tmp)
+
+		return err
+	}
+
 	if err := os.Rename(tmp, path); err != nil {
 		return fmt.Errorf("commit config: %w", err)
 	}
 
 	return nil
 }
+
+// BackupPath returns where WriteConfig keeps the previous config file.
+func BackupPath(path string) string {
+	return path + ".bak"
+}
+
+func backupConfig(path string) error {
+	prev, err := os.ReadFile(path) //nolint:gosec // config file path
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return fmt.Errorf("read config for backup: %w", err)
+	}
+
+	if err := os.WriteFile(BackupPath(path), prev, 0o600); err != nil {
+		return fmt.Errorf("write config backup: %w", err)
+	}
+
+	return nil
+}
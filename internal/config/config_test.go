@@ -0,0 +1,31 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteConfigBytesKeepsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	SetConfigPath(path)
+	t.Cleanup(func() { SetConfigPath("") })
+
+	if err := os.WriteFile(path, []byte("version: 2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := "# my stops\nversion: 2\ndefault_stop: \"200144\" # office\nfuture_key: kept\n"
+	if err := WriteConfigBytes([]byte(edited)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := os.ReadFile(path); string(got) != edited {
+		t.Errorf("config = %q, want %q", got, edited)
+	}
+
+	if got, _ := os.ReadFile(BackupPath(path)); string(got) != "version: 2\n" {
+		t.Errorf("backup = %q", got)
+	}
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultsPrefix = "defaults."
+
+// DefaultValues returns the defaults section flattened to dotted keys,
+// e.g. {"departures.count": "5", "output": "json"}.
+func (f File) DefaultValues() map[string]string {
+	out := make(map[string]string)
+	flattenDefaults(f.Defaults, "", out)
+
+	return out
+}
+
+// topLevelDefaults are the global flag defaults that may be written at the
+// top level of a config file, next to the dotted command keys.
+var topLevelDefaults = map[string]bool{
+	"output":   true,
+	"format":   true,
+	"json":     true,
+	"plain":    true,
+	"template": true,
+	"fields":   true,
+	"no-color": true,
+	"no_color": true,
+	"offline":  true,
+}
+
+// liftFlagDefaults moves flag defaults written at the top level of doc, such
+// as `departures.count: 5` or `output: json`, into its defaults section,
+// where the defaults section wins. Profiles are lifted the same way.
+func liftFlagDefaults(doc map[string]any) {
+	for key, value := range doc {
+		if !strings.Contains(key, ".") && !topLevelDefaults[key] {
+			continue
+		}
+
+		defaults, ok := doc["defaults"].(map[string]any)
+		if !ok {
+			defaults = make(map[string]any)
+			doc["defaults"] = defaults
+		}
+
+		if _, set := defaults[key]; !set {
+			defaults[key] = value
+		}
+
+		delete(doc, key)
+	}
+
+	profiles, _ := doc["profiles"].(map[string]any)
+	for _, p := range profiles {
+		if profile, ok := p.(map[string]any); ok {
+			liftFlagDefaults(profile)
+		}
+	}
+}
+
+// SortedDefaultKeys returns the flattened default keys in display order.
+func SortedDefaultKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func flattenDefaults(m map[string]any, prefix string, out map[string]string) {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		switch val := v.(type) {
+		case map[string]any:
+			flattenDefaults(val, key, out)
+		case []any:
+			parts := make([]string, 0, len(val))
+			for _, item := range val {
+				parts = append(parts, fmt.Sprint(item))
+			}
+
+			out[key] = strings.Join(parts, ",")
+		case nil:
+		default:
+			out[key] = fmt.Sprint(val)
+		}
+	}
+}
+
+// mergeDefaults deep-merges src into dst, returning dst.
+func mergeDefaults(dst, src map[string]any) map[string]any {
+	if dst == nil {
+		dst = make(map[string]any, len(src))
+	}
+
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]any)
+		dstMap, dstIsMap := dst[k].(map[string]any)
+
+		if srcIsMap && dstIsMap {
+			dst[k] = mergeDefaults(maps.Clone(dstMap), srcMap)
+
+			continue
+		}
+
+		dst[k] = v
+	}
+
+	return dst
+}
+
+func defaultKey(path string) Key {
+	parts := strings.Split(path, ".")
+
+	return Key{
+		Name: defaultsPrefix + path,
+		Help: "Default for a command flag",
+		get: func(cfg *File) (string, bool) {
+			v, ok := cfg.DefaultValues()[path]
+
+			return v, ok
+		},
+		set: func(cfg *File, value string) error {
+			if cfg.Defaults == nil {
+				cfg.Defaults = make(map[string]any)
+			}
+
+			// Drop any flat dotted spelling so the nested value wins.
+			delete(cfg.Defaults, path)
+
+			m := cfg.Defaults
+			for _, part := range parts[:len(parts)-1] {
+				next, ok := m[part].(map[string]any)
+				if !ok {
+					next = make(map[string]any)
+					m[part] = next
+				}
+
+				m = next
+			}
+
+			m[parts[len(parts)-1]] = scalarValue(value)
+
+			return nil
+		},
+		unset: func(cfg *File) {
+			delete(cfg.Defaults, path)
+			unsetNested(cfg.Defaults, parts)
+		},
+	}
+}
+
+// scalarValue stores numbers and booleans typed, so config.yaml reads
+// `count: 5` rather than `count: "5"`.
+func scalarValue(value string) any {
+	var v any
+	if err := yaml.Unmarshal([]byte(value), &v); err != nil {
+		return value
+	}
+
+	switch v.(type) {
+	case int, bool, float64:
+		return v
+	default:
+		return value
+	}
+}
+
+func unsetNested(m map[string]any, parts []string) {
+	if m == nil || len(parts) == 0 {
+		return
+	}
+
+	if len(parts) == 1 {
+		delete(m, parts[0])
+
+		return
+	}
+
+	next, ok := m[parts[0]].(map[string]any)
+	if !ok {
+		return
+	}
+
+	unsetNested(next, parts[1:])
+
+	if len(next) == 0 {
+		delete(m, parts[0])
+	}
+}
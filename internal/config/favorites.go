@@ -3,49 +3,72 @@ package config
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 // ErrFavoriteNotFound is returned when a favorite alias doesn't exist.
 var ErrFavoriteNotFound = errors.New("favorite not found")
 
+// Favorite is a named stop.
+type Favorite struct {
+	Stop int    `json:"stop"           yaml:"stop"`
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	Walk string `json:"walk,omitempty" yaml:"walk,omitempty"` // walking time to the stop, e.g. 6m
+}
+
+// WalkTime returns the walking time to the stop, or 0 when it is not set.
+func (f Favorite) WalkTime() time.Duration {
+	d, _ := time.ParseDuration(f.Walk)
+
+	return d
+}
+
 // GetFavorite returns the stop number for a favorite alias.
 func GetFavorite(name string) (int, error) {
-	cfg, err := ReadConfig()
+	fav, err := LookupFavorite(name)
 	if err != nil {
 		return 0, err
 	}
 
-	if cfg.Favorites == nil {
-		return 0, fmt.Errorf("%q: %w", name, ErrFavoriteNotFound)
+	return fav.Stop, nil
+}
+
+// LookupFavorite returns the favorite called name.
+func LookupFavorite(name string) (Favorite, error) {
+	cfg, err := ReadConfig()
+	if err != nil {
+		return Favorite{}, err
 	}
 
-	stopNum, ok := cfg.Favorites[name]
+	fav, ok := cfg.Favorites[name]
 	if !ok {
-		return 0, fmt.Errorf("%q: %w", name, ErrFavoriteNotFound)
+		return Favorite{}, fmt.Errorf("%q: %w", name, ErrFavoriteNotFound)
 	}
 
-	return stopNum, nil
+	return fav, nil
 }
 
-// SetFavorite sets a favorite stop alias.
+// SetFavorite sets a favorite stop alias in the user config.
 func SetFavorite(name string, stopNumber int) error {
-	cfg, err := ReadConfig()
+	cfg, err := ReadUserConfig()
 	if err != nil {
 		return err
 	}
 
 	if cfg.Favorites == nil {
-		cfg.Favorites = make(map[string]int)
+		cfg.Favorites = make(map[string]Favorite)
 	}
 
-	cfg.Favorites[name] = stopNumber
+	fav := cfg.Favorites[name]
+	fav.Stop = stopNumber
+	cfg.Favorites[name] = fav
 
 	return WriteConfig(cfg)
 }
 
-// RemoveFavorite removes a favorite stop alias.
+// RemoveFavorite removes a favorite stop alias from the user config.
 func RemoveFavorite(name string) error {
-	cfg, err := ReadConfig()
+	cfg, err := ReadUserConfig()
 	if err != nil {
 		return err
 	}
@@ -64,14 +87,14 @@ func RemoveFavorite(name string) error {
 }
 
 // ListFavorites returns all configured favorites.
-func ListFavorites() (map[string]int, error) {
+func ListFavorites() (map[string]Favorite, error) {
 	cfg, err := ReadConfig()
 	if err != nil {
 		return nil, err
 	}
 
 	if cfg.Favorites == nil {
-		return make(map[string]int), nil
+		return make(map[string]Favorite), nil
 	}
 
 	return cfg.Favorites, nil
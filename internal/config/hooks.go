@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Hook runs a command or posts JSON to a URL when its rule matches a
+// departure, e.g.
+//
+//	on: delay > 300 at @home line 1
+//	exec: ./page-me.sh
+//
+// Rules are checked by `delijn watch-rules`.
+type Hook struct {
+	On   string `json:"on"             yaml:"on"`
+	Exec string `json:"exec,omitempty" yaml:"exec,omitempty"` // shell command, run with sh -c
+	Post string `json:"post,omitempty" yaml:"post,omitempty"` // http(s) URL
+}
+
+// validateHook checks the shape of hook i; the rule itself is parsed by
+// watch-rules.
+func validateHook(i int, h Hook) error {
+	if strings.TrimSpace(h.On) == "" {
+		return fmt.Errorf("hooks[%d]: %w: on is required", i, ErrInvalidValue)
+	}
+
+	if (h.Exec == "") == (h.Post == "") {
+		return fmt.Errorf("hooks[%d]: %w: set exactly one of exec and post", i, ErrInvalidValue)
+	}
+
+	if h.Post == "" {
+		return nil
+	}
+
+	u, err := url.Parse(h.Post)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("hooks[%d]: %w: post must be an http or https URL, got %q", i, ErrInvalidValue, h.Post)
+	}
+
+	return nil
+}
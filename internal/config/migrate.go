@@ -0,0 +1,95 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CurrentVersion is the config schema version written by this build.
+//
+// History:
+//   - 1: unversioned files; favorites map aliases to stop numbers
+//   - 2: favorites are objects ({stop: 200552})
+const CurrentVersion = 2
+
+// ErrConfigTooNew is returned for files written by a newer delijn.
+var ErrConfigTooNew = errors.New("config version is newer than supported")
+
+// migration upgrades a decoded document from version N to N+1.
+type migration func(doc map[string]any) error
+
+// migrations[i] upgrades from version i+1 to version i+2.
+var migrations = []migration{
+	migrateFavoritesToObjects,
+}
+
+// migrate upgrades doc in place to CurrentVersion and reports the version
+// it started from.
+func migrate(doc map[string]any) (int, error) {
+	from := 1
+
+	if raw, ok := doc["version"]; ok {
+		v, isInt := raw.(int)
+		if !isInt || v < 1 {
+			return 0, fmt.Errorf("%w: version must be a positive integer, got %v", ErrInvalidValue, raw)
+		}
+
+		from = v
+	}
+
+	if from > CurrentVersion {
+		return from, fmt.Errorf("%w: file is version %d, this delijn supports up to %d; upgrade delijn", ErrConfigTooNew, from, CurrentVersion)
+	}
+
+	for v := from; v < CurrentVersion; v++ {
+		if err := migrations[v-1](doc); err != nil {
+			return from, fmt.Errorf("migrate config v%d to v%d: %w", v, v+1, err)
+		}
+	}
+
+	doc["version"] = CurrentVersion
+
+	return from, nil
+}
+
+// migrateFavoritesToObjects turns `home: 200552` into `home: {stop: 200552}`,
+// including favorites inside profiles.
+func migrateFavoritesToObjects(doc map[string]any) error {
+	if err := favoritesToObjects(doc); err != nil {
+		return err
+	}
+
+	profiles, _ := doc["profiles"].(map[string]any)
+	for name, p := range profiles {
+		profile, ok := p.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		if err := favoritesToObjects(profile); err != nil {
+			return fmt.Errorf("profiles.%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func favoritesToObjects(doc map[string]any) error {
+	favorites, ok := doc["favorites"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	for name, v := range favorites {
+		switch stop := v.(type) {
+		case int:
+			favorites[name] = map[string]any{"stop": stop}
+		case map[string]any:
+			// Already an object.
+		default:
+			return fmt.Errorf("favorites.%s: %w: expected a stop number, got %v", name, ErrInvalidValue, v)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseConfigMigratesV1(t *testing.T) {
+	v1 := []byte(`favorites:
+  home: 200552
+timezone: Europe/Brussels
+profiles:
+  work:
+    favorites:
+      office: 300100
+`)
+
+	cfg, warnings, err := ParseConfig(v1)
+	if err != nil {
+		t.Fatalf("ParseConfig() error: %v", err)
+	}
+
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+
+	if cfg.Version != CurrentVersion {
+		t.Errorf("Version = %d, want %d", cfg.Version, CurrentVersion)
+	}
+
+	if got := cfg.Favorites["home"].Stop; got != 200552 {
+		t.Errorf("favorites.home.stop = %d, want 200552", got)
+	}
+
+	if got := cfg.Profiles["work"].Favorites["office"].Stop; got != 300100 {
+		t.Errorf("profiles.work.favorites.office.stop = %d, want 300100", got)
+	}
+}
+
+func TestParseConfigCurrentVersion(t *testing.T) {
+	v2 := []byte(`version: 2
+favorites:
+  home:
+    stop: 200552
+    name: Korenmarkt
+`)
+
+	cfg, _, err := ParseConfig(v2)
+	if err != nil {
+		t.Fatalf("ParseConfig() error: %v", err)
+	}
+
+	if fav := cfg.Favorites["home"]; fav.Stop != 200552 || fav.Name != "Korenmarkt" {
+		t.Errorf("favorites.home = %+v, want stop 200552 named Korenmarkt", fav)
+	}
+}
+
+func TestParseConfigUnknownKeys(t *testing.T) {
+	b := []byte(`version: 2
+timezone: Europe/Brussels
+colour: blue
+favorites:
+  home:
+    stop: 200552
+    walk_time: 5
+`)
+
+	cfg, warnings, err := ParseConfig(b)
+	if err != nil {
+		t.Fatalf("ParseConfig() error: %v", err)
+	}
+
+	if len(warnings) != 2 {
+		t.Errorf("warnings = %v, want 2 unknown keys", warnings)
+	}
+
+	if cfg.Timezone != "Europe/Brussels" || cfg.Favorites["home"].Stop != 200552 {
+		t.Errorf("known keys should still be decoded, got %+v", cfg)
+	}
+}
+
+func TestParseConfigTooNew(t *testing.T) {
+	_, _, err := ParseConfig([]byte("version: 99\n"))
+	if !errors.Is(err, ErrConfigTooNew) {
+		t.Errorf("ParseConfig() error = %v, want ErrConfigTooNew", err)
+	}
+}
+
+func TestParseConfigEmpty(t *testing.T) {
+	cfg, warnings, err := ParseConfig(nil)
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("ParseConfig(nil) = %v, %v", warnings, err)
+	}
+
+	if cfg.Favorites != nil {
+		t.Errorf("empty config should have no favorites, got %v", cfg.Favorites)
+	}
+}
+
+func TestParseConfigTopLevelDefaults(t *testing.T) {
+	b := []byte(`version: 2
+departures.count: 5
+output: json
+defaults:
+  departures:
+    line: "1"
+profiles:
+  tv:
+    no-color: true
+`)
+
+	cfg, warnings, err := ParseConfig(b)
+	if err != nil || len(warnings) != 0 {
+		t.Fatalf("ParseConfig() = %v, %v", warnings, err)
+	}
+
+	got := cfg.DefaultValues()
+	if got["departures.count"] != "5" || got["output"] != "json" || got["departures.line"] != "1" {
+		t.Errorf("defaults = %v", got)
+	}
+
+	if v := cfg.Profiles["tv"].DefaultValues()["no-color"]; v != "true" {
+		t.Errorf("profile no-color = %q, want true", v)
+	}
+}
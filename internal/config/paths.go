@@ -8,7 +8,26 @@ import (
 
 const AppName = "delijn"
 
+// SharedConfigName is the team-shared config file looked up from the
+// working directory upwards.
+const SharedConfigName = "delijn.yaml"
+
+// configPathOverride is set by --config (or DELIJN_CONFIG).
+var configPathOverride string
+
+// SetConfigPath overrides the user config file location.
+// An empty path restores the default.
+func SetConfigPath(path string) {
+	configPathOverride = path
+}
+
+// Dir returns the user config directory, honouring XDG_CONFIG_HOME on
+// every platform.
 func Dir() (string, error) {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" && filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, AppName), nil
+	}
+
 	base, err := os.UserConfigDir()
 	if err != nil {
 		return "", fmt.Errorf("resolve user config dir: %w", err)
@@ -17,6 +36,21 @@ func Dir() (string, error) {
 	return filepath.Join(base, AppName), nil
 }
 
+// CacheDir returns the directory for cached API responses, honouring
+// XDG_CACHE_HOME on every platform.
+func CacheDir() (string, error) {
+	if xdg := os.Getenv("XDG_CACHE_HOME"); xdg != "" && filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, AppName), nil
+	}
+
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve user cache dir: %w", err)
+	}
+
+	return filepath.Join(base, AppName), nil
+}
+
 func EnsureDir() (string, error) {
 	dir, err := Dir()
 	if err != nil {
@@ -30,7 +64,13 @@ func EnsureDir() (string, error) {
 	return dir, nil
 }
 
+// ConfigPath returns the user config file: the --config override if set,
+// otherwise config.yaml in Dir().
 func ConfigPath() (string, error) {
+	if configPathOverride != "" {
+		return configPathOverride, nil
+	}
+
 	dir, err := Dir()
 	if err != nil {
 		return "", err
@@ -39,6 +79,29 @@ func ConfigPath() (string, error) {
 	return filepath.Join(dir, "config.yaml"), nil
 }
 
+// SharedConfigPath returns the nearest delijn.yaml in the working directory
+// or one of its parents, or "" when there is none.
+func SharedConfigPath() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("resolve working dir: %w", err)
+	}
+
+	for {
+		candidate := filepath.Join(dir, SharedConfigName)
+		if info, statErr := os.Stat(candidate); statErr == nil && !info.IsDir() {
+			return candidate, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+
+		dir = parent
+	}
+}
+
 func KeyringDir() (string, error) {
 	dir, err := Dir()
 	if err != nil {
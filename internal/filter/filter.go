@@ -0,0 +1,461 @@
+// Package filter implements the expression language of --where, e.g.
+//
+//	line in ("1", "2") && delay > 120 && type == "TRAM" && dest ~ "zwijnaarde"
+//
+// An expression compares fields with literals using == != < <= > >=, the
+// case-insensitive regular expression match ~ and its negation !~, and
+// [not] in (...). Comparisons combine with && (and), || (or), ! (not) and
+// parentheses. Boolean fields may be used on their own.
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kind is the type of a field.
+type Kind int
+
+const (
+	String Kind = iota
+	Number
+	Bool
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Number:
+		return "number"
+	case Bool:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+// Fields declares the fields an expression may use and their kinds.
+type Fields map[string]Kind
+
+// Names returns the field names, sorted.
+func (f Fields) Names() []string {
+	names := make([]string, 0, len(f))
+	for name := range f {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Record holds the values of one item: string, float64 or bool, matching the
+// declared kinds.
+type Record map[string]any
+
+// ErrSyntax is wrapped by all parse errors.
+var ErrSyntax = errors.New("invalid filter expression")
+
+// SyntaxError is a parse error at a position in the expression.
+type SyntaxError struct {
+	Src string
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at column %d\n  %s\n  %s^", e.Msg, e.Pos+1, e.Src, strings.Repeat(" ", e.Pos))
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return ErrSyntax
+}
+
+func syntaxError(src string, pos int, msg string) error {
+	return &SyntaxError{Src: src, Pos: pos, Msg: msg}
+}
+
+// Filter is a compiled expression.
+type Filter struct {
+	root node
+}
+
+// Compile parses src and checks it against fields.
+func Compile(src string, fields Fields) (*Filter, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{src: src, tokens: tokens, fields: fields}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %s", tok)
+	}
+
+	return &Filter{root: root}, nil
+}
+
+// Match reports whether r satisfies the expression. A nil filter matches
+// everything.
+func (f *Filter) Match(r Record) bool {
+	if f == nil {
+		return true
+	}
+
+	return f.root.eval(r)
+}
+
+type node interface {
+	eval(r Record) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(r Record) bool { return n.left.eval(r) && n.right.eval(r) }
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(r Record) bool { return n.left.eval(r) || n.right.eval(r) }
+
+type notNode struct{ expr node }
+
+func (n notNode) eval(r Record) bool { return !n.expr.eval(r) }
+
+type boolField struct{ name string }
+
+func (n boolField) eval(r Record) bool {
+	v, _ := r[n.name].(bool)
+
+	return v
+}
+
+// compareNode compares a field with one or more literals. For "in" it
+// matches when any literal is equal.
+type compareNode struct {
+	field  string
+	kind   Kind
+	op     string // ==, !=, <, <=, >, >=, ~, !~, in, not in
+	values []any
+	re     *regexp.Regexp
+}
+
+func (n compareNode) eval(r Record) bool {
+	v, ok := r[n.field]
+	if !ok {
+		return false
+	}
+
+	switch n.op {
+	case "~":
+		s, _ := v.(string)
+
+		return n.re.MatchString(s)
+	case "!~":
+		s, _ := v.(string)
+
+		return !n.re.MatchString(s)
+	case "in", "not in":
+		found := false
+
+		for _, lit := range n.values {
+			if compare(v, lit) == 0 {
+				found = true
+
+				break
+			}
+		}
+
+		return found == (n.op == "in")
+	}
+
+	c := compare(v, n.values[0])
+
+	switch n.op {
+	case "==":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+// compare orders two values of the same kind.
+func compare(a, b any) int {
+	switch a := a.(type) {
+	case float64:
+		b, _ := b.(float64)
+
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+
+		return 0
+	case bool:
+		b, _ := b.(bool)
+		if a == b {
+			return 0
+		}
+
+		return 1
+	case string:
+		b, _ := b.(string)
+
+		return strings.Compare(a, b)
+	}
+
+	return 1
+}
+
+type parser struct {
+	src    string
+	tokens []token
+	pos    int
+	fields Fields
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+
+	return tok
+}
+
+func (p *parser) errorf(tok token, format string, args ...any) error {
+	return syntaxError(p.src, tok.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokOr {
+		p.next()
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = orNode{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokAnd {
+		p.next()
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = andNode{left, right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return notNode{expr}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.errorf(closing, "expected \")\", got %s", closing)
+		}
+
+		return expr, nil
+	case tokIdent:
+		return p.parseComparison(tok)
+	case tokEOF:
+		return nil, p.errorf(tok, "expression is incomplete")
+	default:
+		return nil, p.errorf(tok, "expected a field name, got %s", tok)
+	}
+}
+
+func (p *parser) parseComparison(ident token) (node, error) {
+	name := strings.ToLower(ident.text)
+
+	kind, ok := p.fields[name]
+	if !ok {
+		return nil, p.errorf(ident, "unknown field %q (available: %s)", ident.text, strings.Join(p.fields.Names(), ", "))
+	}
+
+	op := p.peek()
+
+	switch {
+	case op.kind == tokOp:
+		p.next()
+
+		return p.parseBinary(name, kind, op)
+	case op.kind == tokIn:
+		p.next()
+
+		return p.parseIn(name, kind, "in")
+	case op.kind == tokNot && strings.EqualFold(op.text, "not"):
+		p.next()
+
+		if in := p.next(); in.kind != tokIn {
+			return nil, p.errorf(in, "expected \"in\" after \"not\", got %s", in)
+		}
+
+		return p.parseIn(name, kind, "not in")
+	case kind == Bool:
+		return boolField{name}, nil
+	default:
+		return nil, p.errorf(op, "expected an operator after %q, got %s", ident.text, op)
+	}
+}
+
+func (p *parser) parseBinary(name string, kind Kind, op token) (node, error) {
+	switch {
+	case (op.text == "~" || op.text == "!~") && kind != String:
+		return nil, p.errorf(op, "%q needs a string field, %s is a %s", op.text, name, kind)
+	case op.text != "==" && op.text != "!=" && kind == Bool:
+		return nil, p.errorf(op, "%q does not apply to boolean field %s", op.text, name)
+	}
+
+	lit := p.next()
+
+	value, err := p.literal(name, kind, lit)
+	if err != nil {
+		return nil, err
+	}
+
+	n := compareNode{field: name, kind: kind, op: op.text, values: []any{value}}
+
+	if op.text == "~" || op.text == "!~" {
+		re, err := regexp.Compile("(?i)" + value.(string))
+		if err != nil {
+			return nil, p.errorf(lit, "invalid regular expression: %v", err)
+		}
+
+		n.re = re
+	}
+
+	return n, nil
+}
+
+func (p *parser) parseIn(name string, kind Kind, op string) (node, error) {
+	if open := p.next(); open.kind != tokLParen {
+		return nil, p.errorf(open, "expected \"(\" after %q, got %s", op, open)
+	}
+
+	n := compareNode{field: name, kind: kind, op: op}
+
+	for {
+		value, err := p.literal(name, kind, p.next())
+		if err != nil {
+			return nil, err
+		}
+
+		n.values = append(n.values, value)
+
+		switch sep := p.next(); sep.kind {
+		case tokComma:
+			continue
+		case tokRParen:
+			return n, nil
+		default:
+			return nil, p.errorf(sep, "expected \",\" or \")\", got %s", sep)
+		}
+	}
+}
+
+// literal converts tok to a value of kind. Number literals compared with a
+// string field are taken as text, so `line == 1` works.
+func (p *parser) literal(name string, kind Kind, tok token) (any, error) {
+	switch tok.kind {
+	case tokString:
+		if kind != String {
+			return nil, p.errorf(tok, "%s is a %s field, got %s", name, kind, tok)
+		}
+
+		return tok.text, nil
+	case tokNumber:
+		if kind == String {
+			return tok.text, nil
+		}
+
+		if kind != Number {
+			return nil, p.errorf(tok, "%s is a %s field, got number %s", name, kind, tok.text)
+		}
+
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid number %q", tok.text)
+		}
+
+		return f, nil
+	case tokIdent:
+		switch strings.ToLower(tok.text) {
+		case "true", "false":
+			if kind != Bool {
+				return nil, p.errorf(tok, "%s is a %s field, got boolean %s", name, kind, tok.text)
+			}
+
+			return strings.EqualFold(tok.text, "true"), nil
+		}
+
+		return nil, p.errorf(tok, "expected a value, got %s (quote strings)", tok)
+	case tokEOF:
+		return nil, p.errorf(tok, "expected a value after the operator")
+	default:
+		return nil, p.errorf(tok, "expected a value, got %s", tok)
+	}
+}
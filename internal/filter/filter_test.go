@@ -0,0 +1,122 @@
+package filter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testFields = Fields{
+	"line":     String,
+	"dest":     String,
+	"type":     String,
+	"delay":    Number,
+	"realtime": Bool,
+}
+
+func TestMatch(t *testing.T) {
+	tram := Record{"line": "1", "dest": "Flanders Expo", "type": "TRAM", "delay": 180.0, "realtime": true}
+	bus := Record{"line": "10", "dest": "Zwijnaarde Campus", "type": "BUS", "delay": 0.0, "realtime": false}
+
+	tests := []struct {
+		expr     string
+		wantTram bool
+		wantBus  bool
+	}{
+		{`line == "1"`, true, false},
+		{`line == 1`, true, false},
+		{`line in ("1", "2")`, true, false},
+		{`line not in ("1", "2")`, false, true},
+		{`delay > 120`, true, false},
+		{`delay <= 0`, false, true},
+		{`type == "TRAM" && delay > 120`, true, false},
+		{`type == "TRAM" || dest ~ "zwijnaarde"`, true, true},
+		{`dest !~ "^flanders"`, false, true},
+		{`realtime`, true, false},
+		{`!realtime`, false, true},
+		{`not (realtime or delay > 0)`, false, true},
+		{`realtime == false`, false, true},
+		{`line in ('1') and type == 'TRAM'`, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := Compile(tt.expr, testFields)
+			if err != nil {
+				t.Fatalf("Compile(%q) error: %v", tt.expr, err)
+			}
+
+			if got := f.Match(tram); got != tt.wantTram {
+				t.Errorf("Match(tram) = %v, want %v", got, tt.wantTram)
+			}
+
+			if got := f.Match(bus); got != tt.wantBus {
+				t.Errorf("Match(bus) = %v, want %v", got, tt.wantBus)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantMsg string
+		wantCol int
+	}{
+		{`line = "1"`, `use "=="`, 6},
+		{`line == "1" & delay > 1`, `use "&&"`, 13},
+		{`colour == "red"`, `unknown field "colour"`, 1},
+		{`delay > "late"`, `delay is a number field`, 9},
+		{`type == TRAM`, `quote strings`, 9},
+		{`line in ("1" "2")`, `expected "," or ")"`, 14},
+		{`(line == "1"`, `expected ")"`, 13},
+		{`line ==`, `expected a value`, 8},
+		{`dest ~ "("`, `invalid regular expression`, 8},
+		{`delay ~ "1"`, `needs a string field`, 7},
+		{`line == "1" delay`, `unexpected "delay"`, 13},
+		{`dest == "open`, `unterminated string`, 9},
+		{``, `incomplete`, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Compile(tt.expr, testFields)
+			if err == nil {
+				t.Fatalf("Compile(%q) should fail", tt.expr)
+			}
+
+			if !errors.Is(err, ErrSyntax) {
+				t.Errorf("error should wrap ErrSyntax, got %v", err)
+			}
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("error should be a *SyntaxError, got %T", err)
+			}
+
+			if !strings.Contains(syntaxErr.Msg, tt.wantMsg) {
+				t.Errorf("message = %q, want it to contain %q", syntaxErr.Msg, tt.wantMsg)
+			}
+
+			if syntaxErr.Pos+1 != tt.wantCol {
+				t.Errorf("column = %d, want %d", syntaxErr.Pos+1, tt.wantCol)
+			}
+		})
+	}
+}
+
+func TestSyntaxErrorCaret(t *testing.T) {
+	_, err := Compile(`line = "1"`, testFields)
+
+	want := "unexpected \"=\", use \"==\" to compare at column 6\n  line = \"1\"\n       ^"
+	if err == nil || err.Error() != want {
+		t.Errorf("Error() =\n%v\nwant\n%s", err, want)
+	}
+}
+
+func TestNilFilterMatches(t *testing.T) {
+	var f *Filter
+	if !f.Match(Record{}) {
+		t.Error("nil filter should match everything")
+	}
+}
@@ -0,0 +1,171 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp     // == != < <= > >= ~ !~
+	tokAnd    // && and
+	tokOr     // || or
+	tokNot    // ! not
+	tokIn     // in
+	tokLParen // (
+	tokRParen // )
+	tokComma  // ,
+)
+
+type token struct {
+	kind tokenKind
+	text string // operator, identifier, or decoded string literal
+	pos  int    // byte offset in the source
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return fmt.Sprintf("string %q", t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// lex splits src into tokens.
+func lex(src string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(src); {
+		c := src[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokComma, ",", i})
+			i++
+		case c == '"' || c == '\'':
+			text, n, err := lexString(src, i)
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, token{tokString, text, i})
+			i += n
+		case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			n := 1
+			for i+n < len(src) && (src[i+n] == '.' || (src[i+n] >= '0' && src[i+n] <= '9')) {
+				n++
+			}
+
+			tokens = append(tokens, token{tokNumber, src[i : i+n], i})
+			i += n
+		case isIdentStart(rune(c)):
+			n := 1
+			for i+n < len(src) && isIdentPart(rune(src[i+n])) {
+				n++
+			}
+
+			word := src[i : i+n]
+			kind := tokIdent
+
+			switch strings.ToLower(word) {
+			case "and":
+				kind = tokAnd
+			case "or":
+				kind = tokOr
+			case "not":
+				kind = tokNot
+			case "in":
+				kind = tokIn
+			}
+
+			tokens = append(tokens, token{kind, word, i})
+			i += n
+		default:
+			tok, err := lexOperator(src, i)
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, tok)
+			i += len(tok.text)
+		}
+	}
+
+	return append(tokens, token{tokEOF, "", len(src)}), nil
+}
+
+func lexOperator(src string, i int) (token, error) {
+	two := ""
+	if i+1 < len(src) {
+		two = src[i : i+2]
+	}
+
+	switch two {
+	case "&&":
+		return token{tokAnd, two, i}, nil
+	case "||":
+		return token{tokOr, two, i}, nil
+	case "==", "!=", "<=", ">=", "!~":
+		return token{tokOp, two, i}, nil
+	}
+
+	switch src[i] {
+	case '<', '>', '~':
+		return token{tokOp, src[i : i+1], i}, nil
+	case '!':
+		return token{tokNot, "!", i}, nil
+	case '=':
+		return token{}, syntaxError(src, i, `unexpected "=", use "==" to compare`)
+	case '&', '|':
+		return token{}, syntaxError(src, i, fmt.Sprintf("unexpected %q, use %q", src[i:i+1], strings.Repeat(src[i:i+1], 2)))
+	}
+
+	return token{}, syntaxError(src, i, fmt.Sprintf("unexpected character %q", src[i:i+1]))
+}
+
+// lexString decodes the quoted string starting at src[start] and returns it
+// with the number of source bytes consumed.
+func lexString(src string, start int) (string, int, error) {
+	quote := src[start]
+
+	var b strings.Builder
+
+	for i := start + 1; i < len(src); i++ {
+		switch c := src[i]; {
+		case c == quote:
+			return b.String(), i - start + 1, nil
+		case c == '\\' && i+1 < len(src):
+			i++
+			b.WriteByte(src[i])
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", 0, syntaxError(src, start, "unterminated string")
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,320 @@
+// Package metrics keeps counters, gauges and histograms and writes them in
+// the Prometheus text exposition format. It covers what the exporter needs,
+// without the dependencies of the Prometheus client library.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Registry holds metrics in the order they were registered.
+type Registry struct {
+	mu       sync.Mutex
+	metrics  []metric
+	onScrape []func()
+}
+
+// metric writes its samples in the exposition format.
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.metrics = append(r.metrics, m)
+}
+
+// OnScrape registers f to run before the metrics are written, to update
+// gauges that are only worth reading when scraped.
+func (r *Registry) OnScrape(f func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.onScrape = append(r.onScrape, f)
+}
+
+// Update runs f while no scrape is in progress, so that a scrape sees the
+// metrics as they were before f or after it, never halfway. f must not
+// register metrics or scrape.
+func (r *Registry) Update(f func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f()
+}
+
+// Counter registers a counter with the given label names.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	c := &Counter{newFamily(name, help, "counter", labels)}
+	r.register(c)
+
+	return c
+}
+
+// Gauge registers a gauge with the given label names.
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{newFamily(name, help, "gauge", labels)}
+	r.register(g)
+
+	return g
+}
+
+// Histogram registers a histogram with the given upper bucket bounds, in
+// increasing order, and label names.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{family: newFamily(name, help, "histogram", labels), buckets: buckets}
+	r.register(h)
+
+	return h
+}
+
+// WriteTo writes every metric in the text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, f := range r.onScrape {
+		f()
+	}
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+
+	for _, m := range r.metrics {
+		m.write(bw)
+	}
+
+	err := bw.Flush()
+
+	return cw.n, err
+}
+
+// ServeHTTP answers a scrape.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	_, _ = r.WriteTo(w)
+}
+
+// family is a metric name with one series per combination of label values.
+type family struct {
+	name, help, kind string
+	labels           []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labels string // rendered label pairs, e.g. `stop="1",line="2"`
+	value  float64
+
+	// Histograms only.
+	counts []uint64
+	count  uint64
+}
+
+func newFamily(name, help, kind string, labels []string) family {
+	return family{name: name, help: help, kind: kind, labels: labels, series: map[string]*series{}}
+}
+
+// get returns the series for values, creating it. The caller holds f.mu.
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s has %d labels, got %d values", f.name, len(f.labels), len(values)))
+	}
+
+	key := strings.Join(values, "\xff")
+
+	s, ok := f.series[key]
+	if !ok {
+		pairs := make([]string, len(values))
+		for i, v := range values {
+			pairs[i] = f.labels[i] + `="` + escapeLabel(v) + `"`
+		}
+
+		s = &series{labels: strings.Join(pairs, ",")}
+		f.series[key] = s
+	}
+
+	return s
+}
+
+// sorted returns the series ordered by their labels, so output is stable.
+// The caller holds f.mu.
+func (f *family) sorted() []*series {
+	out := make([]*series, 0, len(f.series))
+	for _, s := range f.series {
+		out = append(out, s)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].labels < out[j].labels })
+
+	return out
+}
+
+func (f *family) header(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, f.kind)
+}
+
+func (f *family) write(w *bufio.Writer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.header(w)
+
+	for _, s := range f.sorted() {
+		writeSample(w, f.name, s.labels, s.value)
+	}
+}
+
+// Counter is a value that only goes up.
+type Counter struct{ family }
+
+// Add adds v, which must not be negative, to the series for values.
+func (c *Counter) Add(v float64, values ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("metrics: counter %s cannot decrease", c.name))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.get(values).value += v
+}
+
+// Inc adds 1 to the series for values.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct{ family }
+
+// Set sets the series for values to v.
+func (g *Gauge) Set(v float64, values ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.get(values).value = v
+}
+
+// Reset drops every series, for gauges whose label values come and go.
+func (g *Gauge) Reset() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.series = map[string]*series{}
+}
+
+// Histogram counts observations in buckets.
+type Histogram struct {
+	family
+
+	buckets []float64
+}
+
+// Observe records v in the series for values.
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.get(values)
+	if s.counts == nil {
+		s.counts = make([]uint64, len(h.buckets))
+	}
+
+	for i, bound := range h.buckets {
+		if v <= bound {
+			s.counts[i]++
+		}
+	}
+
+	s.count++
+	s.value += v
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.header(w)
+
+	for _, s := range h.sorted() {
+		sep := ""
+		if s.labels != "" {
+			sep = ","
+		}
+
+		for i, bound := range h.buckets {
+			writeSample(w, h.name+"_bucket", s.labels+sep+`le="`+formatValue(bound)+`"`, float64(s.counts[i]))
+		}
+
+		writeSample(w, h.name+"_bucket", s.labels+sep+`le="+Inf"`, float64(s.count))
+		writeSample(w, h.name+"_sum", s.labels, s.value)
+		writeSample(w, h.name+"_count", s.labels, float64(s.count))
+	}
+}
+
+func writeSample(w *bufio.Writer, name, labels string, v float64) {
+	if labels != "" {
+		name += "{" + labels + "}"
+	}
+
+	fmt.Fprintf(w, "%s %s\n", name, formatValue(v))
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+
+	return n, err //nolint:wrapcheck // io.Writer passthrough
+}
@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	r := NewRegistry()
+
+	requests := r.Counter("requests_total", "Requests by code.", "code")
+	requests.Inc("200")
+	requests.Add(2, "500")
+	requests.Inc("200")
+
+	temp := r.Gauge("temperature_celsius", "Temperature.\nIn \\ degrees.", "room")
+	temp.Set(-1.5, `a "quoted"`+"\n"+`back\slash`)
+	temp.Set(math.Inf(1), "hot")
+
+	up := r.Gauge("up", "Up.")
+	up.Set(1)
+
+	latency := r.Histogram("latency_seconds", "Latency.", []float64{0.1, 1}, "api")
+	latency.Observe(0.05, "kern")
+	latency.Observe(0.5, "kern")
+	latency.Observe(3, "kern")
+
+	scrapes := 0
+	r.OnScrape(func() { scrapes++ })
+
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP requests_total Requests by code.
+# TYPE requests_total counter
+requests_total{code="200"} 2
+requests_total{code="500"} 2
+# HELP temperature_celsius Temperature.\nIn \\ degrees.
+# TYPE temperature_celsius gauge
+temperature_celsius{room="a \"quoted\"\nback\\slash"} -1.5
+temperature_celsius{room="hot"} +Inf
+# HELP up Up.
+# TYPE up gauge
+up 1
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{api="kern",le="0.1"} 1
+latency_seconds_bucket{api="kern",le="1"} 2
+latency_seconds_bucket{api="kern",le="+Inf"} 3
+latency_seconds_sum{api="kern"} 3.55
+latency_seconds_count{api="kern"} 3
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteTo() =\n%s\nwant\n%s", got, want)
+	}
+
+	if scrapes != 1 {
+		t.Errorf("OnScrape ran %d times, want 1", scrapes)
+	}
+
+	temp.Reset()
+	b.Reset()
+	_, _ = r.WriteTo(&b)
+
+	if strings.Contains(b.String(), "temperature_celsius{") {
+		t.Errorf("Reset() kept series:\n%s", b.String())
+	}
+}
+
+func TestRegistryUpdate(t *testing.T) {
+	r := NewRegistry()
+	up := r.Gauge("up", "Up.", "stop")
+	up.Set(1, "a")
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for range 100 {
+			r.Update(func() {
+				up.Reset()
+				time.Sleep(100 * time.Microsecond)
+				up.Set(1, "a")
+			})
+		}
+	}()
+
+	for {
+		var b strings.Builder
+		_, _ = r.WriteTo(&b)
+
+		if !strings.Contains(b.String(), `up{stop="a"} 1`) {
+			t.Fatalf("scrape saw an update halfway:\n%s", b.String())
+		}
+
+		select {
+		case <-done:
+			return
+		default:
+		}
+	}
+}
+
+func TestRegistryServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.Gauge("up", "Up.").Set(1)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	if !strings.HasSuffix(rec.Body.String(), "\nup 1\n") {
+		t.Errorf("body = %q", rec.Body)
+	}
+}
+
+func TestLabelCountMismatchPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Set() with too few label values did not panic")
+		}
+	}()
+
+	NewRegistry().Gauge("g", "G.", "a", "b").Set(1, "x")
+}
@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/godbus/dbus"
+)
+
+const (
+	busName    = "org.freedesktop.Notifications"
+	objectPath = dbus.ObjectPath("/org/freedesktop/Notifications")
+	appName    = "delijn"
+
+	urgencyCritical = byte(2)
+	expireDefault   = int32(-1) // the notification server decides
+)
+
+var (
+	// ErrNoSessionBus is returned by NewDBus outside a desktop session.
+	ErrNoSessionBus = errors.New("no D-Bus session bus")
+
+	// ErrNoServer is returned by NewDBus when nothing on the session bus
+	// shows notifications, e.g. on a bus started for the keyring.
+	ErrNoServer = errors.New("no notification server on the session bus")
+)
+
+// DBus posts notifications to org.freedesktop.Notifications.
+type DBus struct {
+	conn *dbus.Conn
+}
+
+// NewDBus connects to the session bus and checks that a notification server
+// runs or can be started there. It does not try to start a bus, so it fails
+// fast over SSH and in containers.
+func NewDBus() (*DBus, error) {
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		return nil, ErrNoSessionBus
+	}
+
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("connect to session bus: %w", err)
+	}
+
+	if !hasServer(conn) {
+		return nil, ErrNoServer
+	}
+
+	return NewDBusConn(conn), nil
+}
+
+// hasServer reports whether the notification service owns its name on conn
+// or is activatable there.
+func hasServer(conn *dbus.Conn) bool {
+	for _, method := range []string{"ListNames", "ListActivatableNames"} {
+		var names []string
+		if err := conn.BusObject().Call("org.freedesktop.DBus."+method, 0).Store(&names); err != nil {
+			continue
+		}
+
+		if slices.Contains(names, busName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewDBusConn posts notifications over conn, e.g. a private bus.
+func NewDBusConn(conn *dbus.Conn) *DBus {
+	return &DBus{conn: conn}
+}
+
+// Notify posts n.
+func (d *DBus) Notify(ctx context.Context, n Notification) error {
+	hints := map[string]dbus.Variant{}
+	if n.Urgent {
+		hints["urgency"] = dbus.MakeVariant(urgencyCritical)
+	}
+
+	call := d.conn.Object(busName, objectPath).CallWithContext(ctx, busName+".Notify", 0,
+		appName, uint32(0), "", n.Summary, n.Body, []string{}, hints, expireDefault)
+	if call.Err != nil {
+		return fmt.Errorf("send notification: %w", call.Err)
+	}
+
+	return nil
+}
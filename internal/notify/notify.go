@@ -0,0 +1,70 @@
+// Package notify posts desktop notifications, over D-Bus where there is a
+// session bus and through a user-configured command elsewhere.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// ErrUnavailable is returned by New when notifications cannot be shown.
+var ErrUnavailable = errors.New("desktop notifications unavailable; set notify_hook to use a command")
+
+// Notification is one desktop notification.
+type Notification struct {
+	Summary string
+	Body    string
+	Urgent  bool // disruptions; shown as critical
+}
+
+// Notifier posts notifications.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// New returns a D-Bus notifier, or one that runs hook when there is no
+// session bus or notification server.
+func New(hook string) (Notifier, error) {
+	d, err := NewDBus()
+	if err == nil {
+		return d, nil
+	}
+
+	if hook != "" {
+		return &Exec{Command: hook}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+}
+
+// Exec shows notifications by running a shell command. The summary and body
+// are passed as $1 and $2, and as DELIJN_SUMMARY, DELIJN_BODY and
+// DELIJN_URGENCY (normal or critical) in the environment.
+type Exec struct {
+	Command string
+}
+
+// Notify runs the command for n.
+func (e *Exec) Notify(ctx context.Context, n Notification) error {
+	urgency := "normal"
+	if n.Urgent {
+		urgency = "critical"
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", e.Command, "delijn", n.Summary, n.Body) //nolint:gosec // user-configured hook
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	cmd.Env = append(os.Environ(),
+		"DELIJN_SUMMARY="+n.Summary,
+		"DELIJN_BODY="+n.Body,
+		"DELIJN_URGENCY="+urgency,
+	)
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run notify hook: %w", err)
+	}
+
+	return nil
+}
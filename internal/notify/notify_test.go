@@ -0,0 +1,155 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus"
+)
+
+func TestExecNotify(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	hook := &Exec{Command: `printf '%s|%s|%s|%s' "$1" "$2" "$DELIJN_SUMMARY" "$DELIJN_URGENCY" > ` + out}
+
+	err := hook.Notify(context.Background(), Notification{Summary: "Line 1 cancelled", Body: "08:04 to Flanders Expo", Urgent: true})
+	if err != nil {
+		t.Fatalf("Notify() error: %v", err)
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Line 1 cancelled|08:04 to Flanders Expo|Line 1 cancelled|critical"
+	if string(got) != want {
+		t.Errorf("hook saw %q, want %q", got, want)
+	}
+}
+
+func TestNewFallsBackToHook(t *testing.T) {
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "")
+
+	n, err := New("true")
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	if _, ok := n.(*Exec); !ok {
+		t.Errorf("New() = %T, want *Exec", n)
+	}
+
+	if _, err := New(""); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("New(\"\") error = %v, want ErrUnavailable", err)
+	}
+}
+
+// server records the notifications sent to it.
+type server struct {
+	got chan []any
+}
+
+func (s *server) Notify(app string, id uint32, icon, summary, body string, actions []string, hints map[string]dbus.Variant, timeout int32) (uint32, *dbus.Error) {
+	s.got <- []any{app, summary, body, hints["urgency"].Value()}
+
+	return 1, nil
+}
+
+func TestDBusNotify(t *testing.T) {
+	addr := privateBus(t)
+
+	srv := dial(t, addr)
+	client := dial(t, addr)
+
+	if hasServer(client) {
+		t.Fatal("hasServer() = true before the server took its name")
+	}
+
+	reply, err := srv.RequestName(busName, dbus.NameFlagDoNotQueue)
+	if err != nil || reply != dbus.RequestNameReplyPrimaryOwner {
+		t.Fatalf("RequestName() = %v, %v", reply, err)
+	}
+
+	s := &server{got: make(chan []any, 1)}
+	if err := srv.Export(s, objectPath, busName); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if !hasServer(client) {
+		t.Fatal("hasServer() = false with the server on the bus")
+	}
+
+	n := NewDBusConn(client)
+	if err := n.Notify(ctx, Notification{Summary: "Leave now", Body: "Line 1", Urgent: true}); err != nil {
+		t.Fatalf("Notify() error: %v", err)
+	}
+
+	got := <-s.got
+	if got[0] != appName || got[1] != "Leave now" || got[2] != "Line 1" || got[3] != urgencyCritical {
+		t.Errorf("server got %v", got)
+	}
+}
+
+// privateBus starts a dbus-daemon for the test and returns its address.
+func privateBus(t *testing.T) string {
+	t.Helper()
+
+	path, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon not installed")
+	}
+
+	daemon := exec.Command(path, "--session", "--nofork", "--print-address")
+
+	stdout, err := daemon.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := daemon.Start(); err != nil {
+		t.Skipf("start dbus-daemon: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = daemon.Process.Kill()
+		_ = daemon.Wait()
+	})
+
+	addr, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Skipf("read dbus-daemon address: %v", err)
+	}
+
+	return strings.TrimSpace(addr)
+}
+
+func dial(t *testing.T, addr string) *dbus.Conn {
+	t.Helper()
+
+	conn, err := dbus.Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	if err := conn.Auth(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.Hello(); err != nil {
+		t.Fatal(err)
+	}
+
+	return conn
+}
@@ -2,6 +2,7 @@ package output
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/muesli/termenv"
 )
@@ -84,18 +85,69 @@ func Cyan(s string) string {
 	return Style(s).Foreground(profile.Color("6")).String()
 }
 
+// ColorEnabled reports whether styled output is on.
+func ColorEnabled() bool {
+	return !noColor && profile != termenv.Ascii
+}
+
+// Strike returns struck-through text.
+func Strike(s string) string {
+	if noColor {
+		return s
+	}
+
+	return Style(s).CrossOut().String()
+}
+
+// LineBadge renders a line number in its De Lijn colours, given as hex codes
+// with or without a leading "#". Missing colours leave the text unstyled.
+func LineBadge(s, fgHex, bgHex string) string {
+	if noColor || fgHex == "" || bgHex == "" {
+		return s
+	}
+
+	return Style(s).
+		Foreground(profile.Color(hexColor(fgHex))).
+		Background(profile.Color(hexColor(bgHex))).
+		Bold().
+		String()
+}
+
+func hexColor(hex string) string {
+	if strings.HasPrefix(hex, "#") {
+		return hex
+	}
+
+	return "#" + hex
+}
+
+// DelayColor returns the colour function for a delay, as used by FormatDelay:
+// early is green, on time dim, late yellow and later than two minutes red.
+func DelayColor(seconds int) func(string) string {
+	switch {
+	case seconds < 0:
+		return Green
+	case seconds == 0:
+		return Dim
+	case seconds < 120:
+		return Yellow
+	default:
+		return Red
+	}
+}
+
 // FormatDelay returns a colored delay string.
 // Negative = early (green), 0 = on time (dim), positive = late (red/yellow).
 func FormatDelay(seconds int) string {
+	color := DelayColor(seconds)
+
 	switch {
 	case seconds < 0:
-		return Green("-" + formatDuration(-seconds))
+		return color("-" + formatDuration(-seconds))
 	case seconds == 0:
-		return Dim("on time")
-	case seconds < 120:
-		return Yellow("+" + formatDuration(seconds))
+		return color("on time")
 	default:
-		return Red("+" + formatDuration(seconds))
+		return color("+" + formatDuration(seconds))
 	}
 }
 
@@ -6,15 +6,6 @@ import (
 	"os"
 )
 
-// Mode represents the output mode.
-type Mode int
-
-const (
-	ModeTable Mode = iota
-	ModePlain
-	ModeJSON
-)
-
 // JSON outputs v as indented JSON to stdout.
 func JSON(v any) error {
 	enc := json.NewEncoder(os.Stdout)
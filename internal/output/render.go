@@ -0,0 +1,431 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats. Columnar formats honour Options.Fields.
+const (
+	FormatTable    = "table"
+	FormatPlain    = "plain"
+	FormatJSON     = "json"
+	FormatNDJSON   = "ndjson"
+	FormatCSV      = "csv"
+	FormatYAML     = "yaml"
+	FormatMarkdown = "markdown"
+	FormatTemplate = "template"
+)
+
+// ErrUnknownField is returned when --fields names a field the listing lacks.
+var ErrUnknownField = errors.New("unknown field")
+
+// Field is a column of a listing.
+type Field struct {
+	Name   string // --fields key, CSV and Markdown header
+	Header string // table header, or label in a details view
+	Table  bool   // shown by the table and Markdown formats by default
+	Plain  bool   // shown by the plain and CSV formats by default
+}
+
+// Record is one row of a listing.
+type Record struct {
+	Item   any               // output DTO, rendered by ndjson and templates
+	Values map[string]string // plain values by field name
+	Styled map[string]string // table values; fields without one use Values
+}
+
+// Listing is command output in a shape every format can render.
+type Listing struct {
+	Document any // the whole document, rendered by json and yaml
+	Fields   []Field
+	Records  []Record
+	Empty    string // table message when there are no records
+	Details  bool   // table shows one "Header: value" line per field
+}
+
+// Options tune rendering.
+type Options struct {
+	Fields   []string // column selection; empty uses the format's defaults
+	Template string   // text/template source for the template format
+}
+
+// Renderer writes a listing in one format.
+type Renderer func(w io.Writer, l Listing, opts Options) error
+
+var (
+	renderers = map[string]Renderer{}
+	columnar  = map[string]bool{}
+)
+
+func init() {
+	Register(FormatTable, true, renderTable)
+	Register(FormatPlain, true, renderPlain)
+	Register(FormatCSV, true, renderCSV)
+	Register(FormatMarkdown, true, renderMarkdown)
+	Register(FormatJSON, false, renderJSON)
+	Register(FormatNDJSON, false, renderNDJSON)
+	Register(FormatYAML, false, renderYAML)
+	Register(FormatTemplate, false, renderTemplate)
+}
+
+// Register adds a format. Columnar formats render fields and accept
+// Options.Fields.
+func Register(name string, isColumnar bool, r Renderer) {
+	renderers[name] = r
+	columnar[name] = isColumnar
+}
+
+// Formats lists the registered format names.
+func Formats() []string {
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Render writes l to w in format.
+func Render(w io.Writer, format string, l Listing, opts Options) error {
+	r, ok := renderers[format]
+	if !ok {
+		return fmt.Errorf("unknown format %q (available: %s)", format, strings.Join(Formats(), ", "))
+	}
+
+	if len(opts.Fields) > 0 && !columnar[format] {
+		return fmt.Errorf("--fields does not apply to %s output", format)
+	}
+
+	return r(w, l, opts)
+}
+
+// FieldNames lists the names of fields, for help and error messages.
+func FieldNames(fields []Field) []string {
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, f.Name)
+	}
+
+	return names
+}
+
+// selectFields returns the fields named in opts, or the defaults picked by
+// def.
+func selectFields(l Listing, opts Options, def func(Field) bool) ([]Field, error) {
+	if len(opts.Fields) == 0 {
+		var fields []Field
+
+		for _, f := range l.Fields {
+			if def(f) {
+				fields = append(fields, f)
+			}
+		}
+
+		return fields, nil
+	}
+
+	byName := make(map[string]Field, len(l.Fields))
+	for _, f := range l.Fields {
+		byName[f.Name] = f
+	}
+
+	fields := make([]Field, 0, len(opts.Fields))
+
+	for _, name := range opts.Fields {
+		f, ok := byName[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("%w %q (available: %s)", ErrUnknownField, name,
+				strings.Join(FieldNames(l.Fields), ", "))
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields, nil
+}
+
+func (r Record) styled(name string) string {
+	if v, ok := r.Styled[name]; ok {
+		return v
+	}
+
+	return r.Values[name]
+}
+
+func renderTable(w io.Writer, l Listing, opts Options) error {
+	fields, err := selectFields(l, opts, func(f Field) bool { return f.Table })
+	if err != nil {
+		return err
+	}
+
+	if len(l.Records) == 0 {
+		if l.Empty != "" {
+			fmt.Fprintln(w, l.Empty)
+		}
+
+		return nil
+	}
+
+	if l.Details {
+		var rows [][]string
+
+		for _, r := range l.Records {
+			for _, f := range fields {
+				if v := r.styled(f.Name); v != "" {
+					rows = append(rows, []string{f.Header + ":", v})
+				}
+			}
+		}
+
+		return writeAligned(w, rows, 1)
+	}
+
+	headers := make([]string, len(fields))
+	for i, f := range fields {
+		headers[i] = f.Header
+	}
+
+	rows := [][]string{headers}
+
+	for _, r := range l.Records {
+		cells := make([]string, len(fields))
+		for i, f := range fields {
+			cells[i] = r.styled(f.Name)
+		}
+
+		rows = append(rows, cells)
+	}
+
+	return writeAligned(w, rows, 2)
+}
+
+// ansiEscape matches the SGR sequences used for colours and styles.
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// visibleWidth is the number of terminal cells s occupies, ignoring escapes.
+func visibleWidth(s string) int {
+	return utf8.RuneCountInString(ansiEscape.ReplaceAllString(s, ""))
+}
+
+// writeAligned writes rows as columns separated by at least padding spaces.
+// Unlike text/tabwriter it ignores colour escapes when measuring cells, so
+// styled cells line up. The last cell of a row is not padded.
+func writeAligned(w io.Writer, rows [][]string, padding int) error {
+	var widths []int
+
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+
+			widths[i] = max(widths[i], visibleWidth(cell))
+		}
+	}
+
+	var b strings.Builder
+
+	for _, row := range rows {
+		for i, cell := range row {
+			b.WriteString(cell)
+
+			if i < len(row)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-visibleWidth(cell)+padding))
+			}
+		}
+
+		b.WriteByte('\n')
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("write output: %w", err)
+	}
+
+	return nil
+}
+
+func renderPlain(w io.Writer, l Listing, opts Options) error {
+	fields, err := selectFields(l, opts, func(f Field) bool { return f.Plain })
+	if err != nil {
+		return err
+	}
+
+	for _, r := range l.Records {
+		cells := make([]string, len(fields))
+		for i, f := range fields {
+			cells[i] = r.Values[f.Name]
+		}
+
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+
+	return nil
+}
+
+func renderCSV(w io.Writer, l Listing, opts Options) error {
+	fields, err := selectFields(l, opts, func(f Field) bool { return f.Plain })
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(FieldNames(fields)); err != nil {
+		return fmt.Errorf("write CSV: %w", err)
+	}
+
+	for _, r := range l.Records {
+		cells := make([]string, len(fields))
+		for i, f := range fields {
+			cells[i] = r.Values[f.Name]
+		}
+
+		if err := cw.Write(cells); err != nil {
+			return fmt.Errorf("write CSV: %w", err)
+		}
+	}
+
+	cw.Flush()
+
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("write CSV: %w", err)
+	}
+
+	return nil
+}
+
+func renderMarkdown(w io.Writer, l Listing, opts Options) error {
+	fields, err := selectFields(l, opts, func(f Field) bool { return f.Table })
+	if err != nil {
+		return err
+	}
+
+	escape := strings.NewReplacer("|", `\|`, "\n", " ")
+
+	row := func(cells []string) {
+		for i, c := range cells {
+			cells[i] = escape.Replace(c)
+		}
+
+		fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+	}
+
+	row(FieldNames(fields))
+
+	rule := make([]string, len(fields))
+	for i := range rule {
+		rule[i] = "---"
+	}
+
+	row(rule)
+
+	for _, r := range l.Records {
+		cells := make([]string, len(fields))
+		for i, f := range fields {
+			cells[i] = r.Values[f.Name]
+		}
+
+		row(cells)
+	}
+
+	return nil
+}
+
+func renderJSON(w io.Writer, l Listing, _ Options) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(l.Document); err != nil {
+		return fmt.Errorf("encode JSON: %w", err)
+	}
+
+	return nil
+}
+
+func renderNDJSON(w io.Writer, l Listing, _ Options) error {
+	enc := json.NewEncoder(w)
+
+	for _, r := range l.Records {
+		if err := enc.Encode(r.Item); err != nil {
+			return fmt.Errorf("encode JSON: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// renderYAML converts the JSON document to YAML, so keys and their order
+// match --json.
+func renderYAML(w io.Writer, l Listing, _ Options) error {
+	b, err := json.Marshal(l.Document)
+	if err != nil {
+		return fmt.Errorf("encode YAML: %w", err)
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return fmt.Errorf("encode YAML: %w", err)
+	}
+
+	blockStyle(&node)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(&node); err != nil {
+		return fmt.Errorf("encode YAML: %w", err)
+	}
+
+	return enc.Close()
+}
+
+// blockStyle clears the flow style JSON input leaves on YAML nodes.
+func blockStyle(n *yaml.Node) {
+	n.Style &^= yaml.FlowStyle | yaml.DoubleQuotedStyle
+
+	for _, c := range n.Content {
+		blockStyle(c)
+	}
+}
+
+func renderTemplate(w io.Writer, l Listing, opts Options) error {
+	if opts.Template == "" {
+		return errors.New("template format needs --template")
+	}
+
+	tmpl, err := template.New("output").Option("missingkey=error").Parse(opts.Template)
+	if err != nil {
+		return fmt.Errorf("parse template: %w", err)
+	}
+
+	for _, r := range l.Records {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, r.Item); err != nil {
+			return fmt.Errorf("execute template: %w", err)
+		}
+
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return fmt.Errorf("write output: %w", err)
+		}
+	}
+
+	return nil
+}
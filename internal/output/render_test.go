@@ -0,0 +1,133 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+type testItem struct {
+	Line        string `json:"line"`
+	Destination string `json:"destination"`
+}
+
+func testListing() Listing {
+	items := []testItem{{"1", "Flanders Expo"}, {"10", "Zwijnaarde | Campus"}}
+	records := make([]Record, 0, len(items))
+
+	for _, it := range items {
+		records = append(records, Record{
+			Item:   it,
+			Values: map[string]string{"line": it.Line, "destination": it.Destination, "delay": "60"},
+			Styled: map[string]string{"delay": "+1m"},
+		})
+	}
+
+	return Listing{
+		Document: map[string]any{"schema_version": 1, "items": items},
+		Fields: []Field{
+			{Name: "line", Header: "LINE", Table: true, Plain: true},
+			{Name: "destination", Header: "DESTINATION", Table: true, Plain: true},
+			{Name: "delay", Header: "DELAY", Table: true},
+		},
+		Records: records,
+		Empty:   "Nothing here.",
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		format string
+		opts   Options
+		want   string
+	}{
+		{FormatTable, Options{}, "LINE  DESTINATION          DELAY\n1     Flanders Expo        +1m\n10    Zwijnaarde | Campus  +1m\n"},
+		{FormatPlain, Options{}, "1\tFlanders Expo\n10\tZwijnaarde | Campus\n"},
+		{FormatPlain, Options{Fields: []string{"delay", "line"}}, "60\t1\n60\t10\n"},
+		{FormatCSV, Options{}, "line,destination\n1,Flanders Expo\n10,Zwijnaarde | Campus\n"},
+		{FormatMarkdown, Options{Fields: []string{"line", "destination"}},
+			"| line | destination |\n| --- | --- |\n| 1 | Flanders Expo |\n| 10 | Zwijnaarde \\| Campus |\n"},
+		{FormatNDJSON, Options{}, `{"line":"1","destination":"Flanders Expo"}` + "\n" +
+			`{"line":"10","destination":"Zwijnaarde | Campus"}` + "\n"},
+		{FormatTemplate, Options{Template: "{{.Line}} -> {{.Destination}}"}, "1 -> Flanders Expo\n10 -> Zwijnaarde | Campus\n"},
+		{FormatYAML, Options{}, "items:\n  - line: \"1\"\n    destination: Flanders Expo\n  - line: \"10\"\n    destination: Zwijnaarde | Campus\nschema_version: 1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Render(&buf, tt.format, testListing(), tt.opts); err != nil {
+				t.Fatalf("Render() error: %v", err)
+			}
+
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Render(%s) =\n%q\nwant\n%q", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderEmptyTable(t *testing.T) {
+	l := testListing()
+	l.Records = nil
+
+	var buf bytes.Buffer
+	if err := Render(&buf, FormatTable, l, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := buf.String(); got != "Nothing here.\n" {
+		t.Errorf("empty table = %q, want the empty message", got)
+	}
+}
+
+func TestRenderDetails(t *testing.T) {
+	l := testListing()
+	l.Records = l.Records[:1]
+	l.Details = true
+
+	var buf bytes.Buffer
+	if err := Render(&buf, FormatTable, l, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "LINE:        1\nDESTINATION: Flanders Expo\nDELAY:       +1m\n"
+	if got := buf.String(); got != want {
+		t.Errorf("details =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestRenderTableIgnoresEscapes(t *testing.T) {
+	l := testListing()
+	l.Records[0].Styled["line"] = "\x1b[1;31m1\x1b[0m"
+
+	var buf bytes.Buffer
+	if err := Render(&buf, FormatTable, l, Options{Fields: []string{"line", "destination"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "LINE  DESTINATION\n\x1b[1;31m1\x1b[0m     Flanders Expo\n10    Zwijnaarde | Campus\n"
+	if got := buf.String(); got != want {
+		t.Errorf("table =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	var buf bytes.Buffer
+
+	if err := Render(&buf, FormatTable, testListing(), Options{Fields: []string{"colour"}}); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("unknown field error = %v, want ErrUnknownField", err)
+	}
+
+	if err := Render(&buf, FormatJSON, testListing(), Options{Fields: []string{"line"}}); err == nil {
+		t.Error("--fields with json should fail")
+	}
+
+	if err := Render(&buf, "xml", testListing(), Options{}); err == nil {
+		t.Error("unknown format should fail")
+	}
+
+	if err := Render(&buf, FormatTemplate, testListing(), Options{Template: "{{.Colour}}"}); err == nil {
+		t.Error("template referencing a missing field should fail")
+	}
+}
@@ -21,21 +21,6 @@ func BrusselsTimezone() *time.Location {
 	return brusselsTZ
 }
 
-// ParseAPITime parses a time string from the De Lijn API.
-// Format: "2006-01-02T15:04:05"
-func ParseAPITime(s string) (time.Time, error) {
-	if s == "" {
-		return time.Time{}, nil
-	}
-
-	t, err := time.ParseInLocation("2006-01-02T15:04:05", s, brusselsTZ)
-	if err != nil {
-		return time.Time{}, fmt.Errorf("parse time %q: %w", s, err)
-	}
-
-	return t, nil
-}
-
 // FormatTime formats a time for display (HH:MM).
 func FormatTime(t time.Time) string {
 	if t.IsZero() {
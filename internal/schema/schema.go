@@ -0,0 +1,501 @@
+// Package schema defines the JSON documents written by --json. Unlike the api
+// types, which mirror De Lijn's Dutch wire format, these use stable English
+// field names and carry a schema_version. Fields are only added within a
+// version; renames and removals bump Version.
+package schema
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+)
+
+// Version is the schema_version of every document in this package.
+const Version = 1
+
+//go:embed schemas/*.json
+var files embed.FS
+
+// Departure is a departure at a stop.
+type Departure struct {
+	Entity             int       `json:"entity"`
+	Stop               int       `json:"stop,omitempty"`
+	TripID             string    `json:"trip_id,omitempty"`
+	LineNumber         int       `json:"line_number"`
+	Line               string    `json:"line"` // public line number
+	Direction          string    `json:"direction"`
+	Destination        string    `json:"destination"`
+	TransportType      string    `json:"transport_type,omitempty"`
+	ScheduledTime      time.Time `json:"scheduled_time"`
+	ExpectedTime       time.Time `json:"expected_time"`
+	DelaySeconds       int       `json:"delay_seconds"`
+	Realtime           bool      `json:"realtime"`
+	Status             string    `json:"status"`
+	PredictionStatuses []string  `json:"prediction_statuses"`
+}
+
+// Location is a WGS84 coordinate.
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Stop is a De Lijn stop.
+type Stop struct {
+	Number           int       `json:"number"`
+	Name             string    `json:"name"`
+	Municipality     string    `json:"municipality"`
+	MunicipalityCode int       `json:"municipality_code"`
+	Entity           int       `json:"entity"`
+	Location         *Location `json:"location,omitempty"`
+}
+
+// Line is a De Lijn line.
+type Line struct {
+	Entity        int    `json:"entity"`
+	LineNumber    int    `json:"line_number"`
+	Line          string `json:"line"` // public line number
+	Description   string `json:"description"`
+	TransportType string `json:"transport_type"`
+	Public        bool   `json:"public"`
+}
+
+// Departures is the output of `delijn departures`. Stop is the first of
+// Stops, the stops whose departures are merged.
+type Departures struct {
+	SchemaVersion int         `json:"schema_version"`
+	Stop          int         `json:"stop"`
+	Stops         []int       `json:"stops"`
+	Departures    []Departure `json:"departures"`
+}
+
+// Snapshot is one refresh of `delijn departures --watch`, written as NDJSON.
+type Snapshot struct {
+	SchemaVersion int         `json:"schema_version"`
+	Timestamp     time.Time   `json:"timestamp"`
+	Stop          int         `json:"stop"`
+	Stops         []int       `json:"stops"`
+	Departures    []Departure `json:"departures"`
+	Error         string      `json:"error,omitempty"`
+	StaleSince    *time.Time  `json:"stale_since,omitempty"`
+}
+
+// Leave is the output of `delijn leave`.
+type Leave struct {
+	SchemaVersion int         `json:"schema_version"`
+	Stop          int         `json:"stop"`
+	WalkSeconds   int         `json:"walk_seconds"`
+	Departures    []LeaveTime `json:"departures"`
+}
+
+// LeaveTime is a departure and when to leave to catch it.
+type LeaveTime struct {
+	LeaveAt   time.Time `json:"leave_at"`
+	Departure Departure `json:"departure"`
+}
+
+// Board is the output of `delijn departures --group-by`.
+type Board struct {
+	SchemaVersion int          `json:"schema_version"`
+	Stop          int          `json:"stop"`
+	Stops         []int        `json:"stops"`
+	GroupBy       string       `json:"group_by"`
+	Groups        []BoardGroup `json:"groups"`
+}
+
+// BoardGroup is one row of a board: the next departures of a line, a
+// destination or a line direction.
+type BoardGroup struct {
+	Lines       []string    `json:"lines"` // public line numbers
+	Destination string      `json:"destination"`
+	Direction   string      `json:"direction"`
+	Departures  []Departure `json:"departures"`
+}
+
+// Event is one line of `delijn events`, written as NDJSON.
+type Event struct {
+	SchemaVersion      int        `json:"schema_version"`
+	Type               string     `json:"type"`
+	Time               time.Time  `json:"time"`
+	Stop               int        `json:"stop"`
+	Departure          *Departure `json:"departure,omitempty"`
+	DelaySeconds       int        `json:"delay_seconds,omitempty"`
+	DelayChangeSeconds int        `json:"delay_change_seconds,omitempty"`
+	Error              string     `json:"error,omitempty"`
+}
+
+// Hook is the payload of a `delijn watch-rules` hook: JSON on the stdin of
+// an exec hook, the body of a post hook and one line of --json.
+type Hook struct {
+	SchemaVersion      int       `json:"schema_version"`
+	Rule               string    `json:"rule"`
+	Event              string    `json:"event,omitempty"`
+	Time               time.Time `json:"time"`
+	Stop               int       `json:"stop"`
+	Departure          Departure `json:"departure"`
+	DelayChangeSeconds int       `json:"delay_change_seconds,omitempty"`
+}
+
+// Trip is the output of `delijn trip`: the calls of one trip still to come.
+type Trip struct {
+	SchemaVersion int        `json:"schema_version"`
+	TripID        string     `json:"trip_id"`
+	Entity        int        `json:"entity"`
+	LineNumber    int        `json:"line_number"`
+	Line          string     `json:"line"` // public line number
+	Direction     string     `json:"direction"`
+	Stops         []TripStop `json:"stops"`
+}
+
+// TripStop is a call of a trip at a stop.
+type TripStop struct {
+	Stop          int       `json:"stop"`
+	Name          string    `json:"name,omitempty"`
+	ScheduledTime time.Time `json:"scheduled_time"`
+	ExpectedTime  time.Time `json:"expected_time"`
+	DelaySeconds  int       `json:"delay_seconds"`
+	Realtime      bool      `json:"realtime"`
+	Status        string    `json:"status"`
+}
+
+// Stops is the output of `delijn stops search`.
+type Stops struct {
+	SchemaVersion int    `json:"schema_version"`
+	Stops         []Stop `json:"stops"`
+}
+
+// StopDetails is the output of `delijn stops get`.
+type StopDetails struct {
+	SchemaVersion int  `json:"schema_version"`
+	Stop          Stop `json:"stop"`
+}
+
+// Lines is the output of `delijn lines search`.
+type Lines struct {
+	SchemaVersion int    `json:"schema_version"`
+	Lines         []Line `json:"lines"`
+}
+
+// LineDetails is the output of `delijn lines get`.
+type LineDetails struct {
+	SchemaVersion int  `json:"schema_version"`
+	Line          Line `json:"line"`
+}
+
+// Favorites is the favorites document of `delijn config list-favorites` and
+// `delijn serve`.
+type Favorites struct {
+	SchemaVersion int        `json:"schema_version"`
+	Favorites     []Favorite `json:"favorites"`
+}
+
+// Favorite is a saved stop.
+type Favorite struct {
+	Alias       string `json:"alias"`
+	Stop        int    `json:"stop"`
+	Name        string `json:"name,omitempty"`
+	WalkSeconds int    `json:"walk_seconds,omitempty"`
+}
+
+// Disruptions is the disruptions document of `delijn serve`.
+type Disruptions struct {
+	SchemaVersion int          `json:"schema_version"`
+	Stops         []int        `json:"stops"`
+	Disruptions   []Disruption `json:"disruptions"`
+}
+
+// Disruption is a service disruption or diversion that affects a stop.
+type Disruption struct {
+	Stop        int        `json:"stop"`
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Type        string     `json:"type"`
+	Start       *time.Time `json:"start,omitempty"`
+	End         *time.Time `json:"end,omitempty"`
+	Lines       []string   `json:"lines"`
+}
+
+// NewDeparture converts an API departure. Its times must be parsed.
+func NewDeparture(d api.Departure) Departure {
+	line := d.LinePublicNumber
+	if line == "" {
+		line = strconv.Itoa(d.LineNumber)
+	}
+
+	statuses := make([]string, 0, len(d.PredictionStatus))
+	for _, s := range d.PredictionStatus {
+		statuses = append(statuses, string(s))
+	}
+
+	return Departure{
+		Entity:             d.EntityNumber,
+		Stop:               d.StopNumber,
+		TripID:             tripID(d),
+		LineNumber:         d.LineNumber,
+		Line:               line,
+		Direction:          d.Direction,
+		Destination:        d.Destination,
+		TransportType:      d.TransportType,
+		ScheduledTime:      d.ScheduledTime,
+		ExpectedTime:       d.ExpectedTime(),
+		DelaySeconds:       d.DelaySeconds(),
+		Realtime:           d.IsRealTime(),
+		Status:             d.Status(),
+		PredictionStatuses: statuses,
+	}
+}
+
+// NewDepartureList converts API departures, never returning nil.
+func NewDepartureList(departures []api.Departure) []Departure {
+	out := make([]Departure, 0, len(departures))
+	for _, d := range departures {
+		out = append(out, NewDeparture(d))
+	}
+
+	return out
+}
+
+// NewDepartures builds the departures document for one or more stops.
+func NewDepartures(stops []int, departures []api.Departure) Departures {
+	return Departures{SchemaVersion: Version, Stop: stops[0], Stops: stops, Departures: NewDepartureList(departures)}
+}
+
+// NewBoard builds the board document for one or more stops from departures
+// already grouped by groupBy. Each group must hold at least one departure.
+func NewBoard(stops []int, groupBy string, groups [][]api.Departure) Board {
+	board := Board{SchemaVersion: Version, Stop: stops[0], Stops: stops, GroupBy: groupBy, Groups: make([]BoardGroup, 0, len(groups))}
+
+	for _, g := range groups {
+		deps := NewDepartureList(g)
+
+		var lines []string
+
+		for _, d := range deps {
+			if !slices.Contains(lines, d.Line) {
+				lines = append(lines, d.Line)
+			}
+		}
+
+		board.Groups = append(board.Groups, BoardGroup{
+			Lines:       lines,
+			Destination: deps[0].Destination,
+			Direction:   deps[0].Direction,
+			Departures:  deps,
+		})
+	}
+
+	return board
+}
+
+// NewTrip builds the trip document. line is the public line number and
+// names maps stop numbers to names; either may be empty.
+func NewTrip(ref api.TripRef, line string, passages []api.TripPassage, names map[int]string) Trip {
+	if line == "" {
+		line = strconv.Itoa(ref.Line)
+	}
+
+	trip := Trip{
+		SchemaVersion: Version,
+		TripID:        ref.String(),
+		Entity:        ref.Entity,
+		LineNumber:    ref.Line,
+		Line:          line,
+		Direction:     ref.Direction,
+		Stops:         make([]TripStop, 0, len(passages)),
+	}
+
+	for _, p := range passages {
+		trip.Stops = append(trip.Stops, TripStop{
+			Stop:          p.Stop,
+			Name:          names[p.Stop],
+			ScheduledTime: p.ScheduledTime,
+			ExpectedTime:  p.ExpectedTime(),
+			DelaySeconds:  p.DelaySeconds(),
+			Realtime:      p.IsRealTime(),
+			Status:        p.Status(),
+		})
+	}
+
+	return trip
+}
+
+func tripID(d api.Departure) string {
+	if ref, ok := d.TripRef(); ok {
+		return ref.String()
+	}
+
+	return ""
+}
+
+// NewEvent converts a departure change event.
+func NewEvent(e api.Event) Event {
+	event := Event{
+		SchemaVersion:      Version,
+		Type:               string(e.Type),
+		Time:               e.Time,
+		Stop:               e.Stop,
+		DelaySeconds:       e.Delay,
+		DelayChangeSeconds: e.Change,
+		Error:              e.Error,
+	}
+
+	if e.Departure != nil {
+		d := NewDeparture(*e.Departure)
+		event.Departure = &d
+	}
+
+	return event
+}
+
+// NewHook describes rule matching the departure of e. A departure that did
+// not change in the refresh comes as an event without a type.
+func NewHook(rule string, e api.Event) Hook {
+	return Hook{
+		SchemaVersion:      Version,
+		Rule:               rule,
+		Event:              string(e.Type),
+		Time:               e.Time,
+		Stop:               e.Stop,
+		Departure:          NewDeparture(*e.Departure),
+		DelayChangeSeconds: e.Change,
+	}
+}
+
+// NewStop converts an API stop.
+func NewStop(s api.Stop) Stop {
+	stop := Stop{
+		Number:           s.Number,
+		Name:             s.Description,
+		Municipality:     s.Municipality,
+		MunicipalityCode: s.MunicipalityCode,
+		Entity:           s.EntityNumber,
+	}
+
+	if s.GeoCoordinate != nil {
+		stop.Location = &Location{Latitude: s.GeoCoordinate.Latitude, Longitude: s.GeoCoordinate.Longitude}
+	}
+
+	return stop
+}
+
+// NewStops builds the stop search document.
+func NewStops(stops []api.Stop) Stops {
+	out := Stops{SchemaVersion: Version, Stops: make([]Stop, 0, len(stops))}
+	for _, s := range stops {
+		out.Stops = append(out.Stops, NewStop(s))
+	}
+
+	return out
+}
+
+// NewStopDetails builds the single stop document.
+func NewStopDetails(s api.Stop) StopDetails {
+	return StopDetails{SchemaVersion: Version, Stop: NewStop(s)}
+}
+
+// NewLine converts an API line.
+func NewLine(l api.Line) Line {
+	line := l.PublicNumber
+	if line == "" {
+		line = strconv.Itoa(l.LineNumber)
+	}
+
+	return Line{
+		Entity:        l.EntityNumber,
+		LineNumber:    l.LineNumber,
+		Line:          line,
+		Description:   l.Description,
+		TransportType: l.TransportType,
+		Public:        l.IsPublic,
+	}
+}
+
+// NewLines builds the line search document.
+func NewLines(lines []api.Line) Lines {
+	out := Lines{SchemaVersion: Version, Lines: make([]Line, 0, len(lines))}
+	for _, l := range lines {
+		out.Lines = append(out.Lines, NewLine(l))
+	}
+
+	return out
+}
+
+// NewLineDetails builds the single line document.
+func NewLineDetails(l api.Line) LineDetails {
+	return LineDetails{SchemaVersion: Version, Line: NewLine(l)}
+}
+
+// NewDisruption converts an API disruption at stop. Its times must be
+// parsed.
+func NewDisruption(stop int, d api.Disruption) Disruption {
+	out := Disruption{
+		Stop:        stop,
+		ID:          d.ID,
+		Title:       d.Title,
+		Description: d.Description,
+		Type:        d.Type,
+		Lines:       make([]string, 0, len(d.Lines)),
+	}
+
+	if !d.StartDate.IsZero() {
+		out.Start = &d.StartDate
+	}
+
+	if !d.EndDate.IsZero() {
+		out.End = &d.EndDate
+	}
+
+	for _, l := range d.Lines {
+		out.Lines = append(out.Lines, NewLine(l).Line)
+	}
+
+	return out
+}
+
+// NewLeave builds the leave document: when to leave for each departure,
+// walk ahead of it.
+func NewLeave(stop int, walk time.Duration, departures []api.Departure) Leave {
+	leave := Leave{SchemaVersion: Version, Stop: stop, WalkSeconds: int(walk.Seconds()), Departures: make([]LeaveTime, 0, len(departures))}
+
+	for _, d := range departures {
+		leave.Departures = append(leave.Departures, LeaveTime{
+			LeaveAt:   d.ExpectedTime().Add(-walk),
+			Departure: NewDeparture(d),
+		})
+	}
+
+	return leave
+}
+
+// Names lists the published schemas, e.g. "departures".
+func Names() []string {
+	entries, _ := fs.ReadDir(files, "schemas")
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".json"))
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Lookup returns the JSON Schema document called name.
+func Lookup(name string) ([]byte, error) {
+	b, err := files.ReadFile(path.Join("schemas", name+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("unknown schema %q (available: %s)", name, strings.Join(Names(), ", "))
+	}
+
+	return b, nil
+}
@@ -0,0 +1,158 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+)
+
+type jsonSchema struct {
+	Ref        string                 `json:"$ref"`
+	Type       string                 `json:"type"`
+	Required   []string               `json:"required"`
+	Properties map[string]*jsonSchema `json:"properties"`
+	Items      *jsonSchema            `json:"items"`
+	Defs       map[string]*jsonSchema `json:"$defs"`
+}
+
+// checkFields compares the properties of s with the JSON fields of t,
+// following $refs into defs.
+func checkFields(t *testing.T, where string, s *jsonSchema, typ reflect.Type, defs map[string]*jsonSchema) {
+	t.Helper()
+
+	if s.Ref != "" {
+		s = defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		if s == nil {
+			t.Fatalf("%s: unresolved $ref", where)
+		}
+	}
+
+	for typ.Kind() == reflect.Pointer || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+		if s.Items != nil {
+			checkFields(t, where+"[]", s.Items, typ, defs)
+
+			return
+		}
+	}
+
+	if typ.Kind() != reflect.Struct || typ == reflect.TypeFor[time.Time]() {
+		return
+	}
+
+	fields := make(map[string]bool)
+
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		fields[name] = true
+
+		prop, ok := s.Properties[name]
+		if !ok {
+			t.Errorf("%s.%s is missing from the schema", where, name)
+
+			continue
+		}
+
+		required := slices.Contains(s.Required, name)
+		if omitempty := strings.Contains(opts, "omitempty"); omitempty == required {
+			t.Errorf("%s.%s: required = %v, but omitempty = %v", where, name, required, omitempty)
+		}
+
+		checkFields(t, where+"."+name, prop, field.Type, defs)
+	}
+
+	for name := range s.Properties {
+		if !fields[name] {
+			t.Errorf("%s.%s is in the schema but not in %s", where, name, typ)
+		}
+	}
+}
+
+func TestSchemasMatchTypes(t *testing.T) {
+	docs := map[string]any{
+		"departures":  Departures{},
+		"watch":       Snapshot{},
+		"board":       Board{},
+		"trip":        Trip{},
+		"leave":       Leave{},
+		"event":       Event{},
+		"hook":        Hook{},
+		"favorites":   Favorites{},
+		"disruptions": Disruptions{},
+		"stops":       Stops{},
+		"stop":        StopDetails{},
+		"lines":       Lines{},
+		"line":        LineDetails{},
+	}
+
+	if names := Names(); len(names) != len(docs) {
+		t.Errorf("Names() = %v, want one schema per document type", names)
+	}
+
+	for name, doc := range docs {
+		b, err := Lookup(name)
+		if err != nil {
+			t.Fatalf("Lookup(%q) error: %v", name, err)
+		}
+
+		var s jsonSchema
+		if err := json.Unmarshal(b, &s); err != nil {
+			t.Fatalf("%s.json is not valid JSON: %v", name, err)
+		}
+
+		checkFields(t, name, &s, reflect.TypeOf(doc), s.Defs)
+	}
+}
+
+func TestLookupUnknown(t *testing.T) {
+	if _, err := Lookup("trips"); err == nil {
+		t.Error("Lookup(\"trips\") should fail")
+	}
+}
+
+func TestNewDeparture(t *testing.T) {
+	d := api.Departure{
+		EntityNumber:     2,
+		LineNumber:       1,
+		LinePublicNumber: "1",
+		ScheduledTimeRaw: "2026-03-01T08:10:00",
+		RealTimeRaw:      "2026-03-01T08:12:00",
+		PredictionStatus: []api.PredictionStatus{api.StatusRealtime},
+	}
+	d.ParseTimes()
+
+	b, err := json.Marshal(NewDepartures([]int{200552}, []api.Departure{d}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`"schema_version":1`,
+		`"scheduled_time":"2026-03-01T08:10:00+01:00"`,
+		`"expected_time":"2026-03-01T08:12:00+01:00"`,
+		`"delay_seconds":120`,
+		`"realtime":true`,
+		`"status":"realtime"`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("json = %s, want it to contain %s", b, want)
+		}
+	}
+}
+
+func TestNewDepartureListEmpty(t *testing.T) {
+	b, err := json.Marshal(NewDepartures([]int{200552}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(b), `"departures":[]`) {
+		t.Errorf("json = %s, want an empty departures array", b)
+	}
+}
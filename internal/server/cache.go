@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// cache keeps successful answers in memory until they expire.
+type cache struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+type entry struct {
+	ready   chan struct{} // closed once resp is set
+	done    bool
+	resp    response
+	expires time.Time // zero for answers that are not kept
+}
+
+func newCache() *cache {
+	return &cache{entries: map[string]*entry{}}
+}
+
+// get returns the answer for key, calling fetch when there is no fresh one.
+// Requests that arrive while fetch runs wait for its answer instead of
+// calling it again. Only 200 answers are kept.
+func (c *cache) get(key string, ttl time.Duration, fetch func() response) response {
+	c.mu.Lock()
+
+	if e, ok := c.entries[key]; ok && (!e.done || time.Now().Before(e.expires)) {
+		c.mu.Unlock()
+		<-e.ready
+
+		return e.resp
+	}
+
+	e := &entry{ready: make(chan struct{})}
+	c.entries[key] = e
+	c.prune(time.Now())
+	c.mu.Unlock()
+
+	resp := fetch()
+
+	c.mu.Lock()
+	e.resp, e.done = resp, true
+
+	if resp.status == http.StatusOK {
+		e.expires = time.Now().Add(ttl)
+	}
+
+	c.mu.Unlock()
+	close(e.ready)
+
+	return resp
+}
+
+// prune drops the expired answers. c.mu must be held.
+func (c *cache) prune(now time.Time) {
+	for key, e := range c.entries {
+		if e.done && !now.Before(e.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// Package server serves De Lijn data as a small JSON REST API, so that one
+// API key, rate limiter and cache can back several dashboards.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+)
+
+const (
+	// requestTimeout bounds the work behind one answer.
+	requestTimeout = 30 * time.Second
+
+	// shutdownTimeout is how long requests in flight get to finish on
+	// shutdown.
+	shutdownTimeout = 10 * time.Second
+)
+
+// Handler answers a request with a document to encode as JSON.
+type Handler func(r *http.Request) (any, error)
+
+// Error is an error answered with a specific HTTP status.
+type Error struct {
+	Status int
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Errorf returns an Error with status.
+func Errorf(status int, format string, args ...any) error {
+	return &Error{Status: status, Err: fmt.Errorf(format, args...)}
+}
+
+// Server routes requests to handlers. Requests need the bearer token when
+// one is set, and answers are cached in memory per route.
+type Server struct {
+	mux   *http.ServeMux
+	token string
+	cache *cache
+}
+
+// New returns a server that requires token, unless it is empty.
+func New(token string) *Server {
+	s := &Server{mux: http.NewServeMux(), token: token, cache: newCache()}
+
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		encode(nil, Errorf(http.StatusNotFound, "no route for %s", r.URL.Path)).write(w)
+	})
+
+	return s
+}
+
+// Handle registers h for pattern, e.g. "GET /departures/{stop}". Answers
+// are cached for ttl; concurrent requests for the same URL share one
+// answer. A ttl of 0 disables caching.
+func (s *Server) Handle(pattern string, ttl time.Duration, h Handler) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		answer := func() response {
+			// A client that hangs up must not fail the requests sharing
+			// this answer.
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), requestTimeout)
+			defer cancel()
+
+			return encode(h(r.WithContext(ctx)))
+		}
+
+		var resp response
+		if ttl > 0 {
+			resp = s.cache.get(r.URL.Path+"?"+r.URL.Query().Encode(), ttl, answer)
+		} else {
+			resp = answer()
+		}
+
+		resp.write(w)
+	})
+}
+
+// ServeHTTP checks the token and serves r.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			encode(nil, Errorf(http.StatusUnauthorized, "missing or wrong bearer token")).write(w)
+
+			return
+		}
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// Run serves on ln until ctx is done, then shuts down gracefully.
+func (s *Server) Run(ctx context.Context, ln net.Listener) error {
+	srv := &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+
+	served := make(chan error, 1)
+
+	go func() {
+		served <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-served:
+		return fmt.Errorf("serve: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shut down: %w", err)
+	}
+
+	return nil
+}
+
+// response is an encoded answer.
+type response struct {
+	status     int
+	body       []byte
+	retryAfter int // seconds, for 429
+}
+
+func (r response) write(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(r.retryAfter))
+	}
+
+	w.WriteHeader(r.status)
+	_, _ = w.Write(r.body)
+}
+
+func encode(doc any, err error) response {
+	if err != nil {
+		status, retryAfter := errorStatus(err)
+		body, _ := json.Marshal(struct {
+			Error string `json:"error"`
+		}{err.Error()})
+
+		return response{status: status, body: append(body, '\n'), retryAfter: retryAfter}
+	}
+
+	body, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return encode(nil, fmt.Errorf("encode response: %w", err))
+	}
+
+	return response{status: http.StatusOK, body: append(body, '\n')}
+}
+
+// errorStatus maps err to an HTTP status. Errors of the De Lijn API are the
+// upstream's fault, so they answer 502 unless the resource does not exist.
+func errorStatus(err error) (status, retryAfter int) {
+	var (
+		serverErr  *Error
+		apiErr     *api.APIError
+		rateErr    *api.RateLimitError
+		circuitErr *api.CircuitBreakerError
+		authErr    *api.AuthError
+	)
+
+	switch {
+	case errors.As(err, &serverErr):
+		return serverErr.Status, 0
+	case errors.As(err, &rateErr):
+		return http.StatusTooManyRequests, rateErr.RetryAfter
+	case errors.Is(err, api.ErrRateLimited):
+		return http.StatusTooManyRequests, 0
+	case errors.As(err, &circuitErr), errors.As(err, &authErr), errors.Is(err, api.ErrNotCached):
+		return http.StatusServiceUnavailable, 0
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound, errors.Is(err, api.ErrNotFound):
+		return http.StatusNotFound, 0
+	case errors.As(err, &apiErr):
+		return http.StatusBadGateway, 0
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, 0
+	default:
+		return http.StatusInternalServerError, 0
+	}
+}
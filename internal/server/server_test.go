@@ -0,0 +1,166 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+)
+
+func get(t *testing.T, h http.Handler, path, token string) (int, string) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec.Code, rec.Body.String()
+}
+
+func TestServerToken(t *testing.T) {
+	s := New("secret")
+	s.Handle("GET /ping", 0, func(*http.Request) (any, error) { return map[string]bool{"ok": true}, nil })
+
+	tests := []struct {
+		token string
+		want  int
+	}{
+		{"", http.StatusUnauthorized},
+		{"wrong", http.StatusUnauthorized},
+		{"secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		if code, _ := get(t, s, "/ping", tt.token); code != tt.want {
+			t.Errorf("token %q: status = %d, want %d", tt.token, code, tt.want)
+		}
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{Errorf(http.StatusBadRequest, "bad stop"), http.StatusBadRequest},
+		{fmt.Errorf("get line: %w", &api.APIError{StatusCode: 404, Message: "not found"}), http.StatusNotFound},
+		{&api.APIError{StatusCode: 500, Message: "boom"}, http.StatusBadGateway},
+		{&api.RateLimitError{RetryAfter: 30}, http.StatusTooManyRequests},
+		{&api.CircuitBreakerError{}, http.StatusServiceUnavailable},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		s := New("")
+		s.Handle("GET /fail", time.Minute, func(*http.Request) (any, error) { return nil, tt.err })
+
+		code, body := get(t, s, "/fail", "")
+		if code != tt.want {
+			t.Errorf("%v: status = %d, want %d", tt.err, code, tt.want)
+		}
+
+		if want := fmt.Sprintf(`{"error":%q}`+"\n", tt.err.Error()); body != want {
+			t.Errorf("%v: body = %s, want %s", tt.err, body, want)
+		}
+	}
+
+	if code, _ := get(t, New(""), "/nowhere", ""); code != http.StatusNotFound {
+		t.Errorf("unknown route: status = %d, want 404", code)
+	}
+}
+
+func TestServerCache(t *testing.T) {
+	var calls atomic.Int32
+
+	release := make(chan struct{})
+
+	s := New("")
+	s.Handle("GET /departures/{stop}", time.Minute, func(r *http.Request) (any, error) {
+		calls.Add(1)
+		<-release
+
+		return map[string]string{"stop": r.PathValue("stop")}, nil
+	})
+
+	var wg sync.WaitGroup
+
+	for range 5 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if code, body := get(t, s, "/departures/200144", ""); code != http.StatusOK || body != "{\n  \"stop\": \"200144\"\n}\n" {
+				t.Errorf("status %d, body %s", code, body)
+			}
+		}()
+	}
+
+	// Let the requests queue up behind the first one.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	get(t, s, "/departures/200144", "")
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times for one URL, want 1", n)
+	}
+
+	get(t, s, "/departures/200145", "")
+
+	if n := calls.Load(); n != 2 {
+		t.Errorf("handler ran %d times for two URLs, want 2", n)
+	}
+}
+
+func TestServerRunShutsDown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := New("")
+	s.Handle("GET /ping", 0, func(*http.Request) (any, error) { return "pong", nil })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() { done <- s.Run(ctx, ln) }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != "\"pong\"\n" {
+		t.Errorf("body = %q", body)
+	}
+
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return after cancel")
+	}
+}
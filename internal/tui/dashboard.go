@@ -0,0 +1,437 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/output"
+)
+
+const (
+	minPanelWidth  = 40
+	minPanelHeight = 4               // title, header and two departures
+	delayWidth     = 9               // fits "CANCELLED"
+	rowFixedWidth  = 14 + delayWidth // a row without its destination
+)
+
+// Panel is one stop on the dashboard.
+type Panel struct {
+	Title string
+	Fetch func(ctx context.Context) ([]api.Departure, error)
+
+	departures []api.Departure // from the last successful fetch
+	err        error
+	updated    time.Time
+	loading    bool
+	next       time.Time // when the panel is fetched again
+	backoff    *api.Backoff
+}
+
+// Dashboard shows the departures of several stops side by side.
+type Dashboard struct {
+	panels   []*Panel
+	interval time.Duration
+
+	active  int
+	filter  []string // line numbers; empty shows every line
+	editing bool     // typing a filter
+	input   string
+}
+
+// NewDashboard returns a dashboard of panels refreshed every interval. A
+// panel whose fetch fails keeps its last departures, marked stale, and backs
+// off while the API is throttling.
+func NewDashboard(panels []*Panel, interval time.Duration) *Dashboard {
+	for _, p := range panels {
+		p.backoff = api.NewBackoff(interval)
+	}
+
+	return &Dashboard{panels: panels, interval: interval}
+}
+
+type action int
+
+const (
+	actionNone action = iota
+	actionRefresh
+	actionQuit
+)
+
+type fetchResult struct {
+	panel      int
+	departures []api.Departure
+	err        error
+}
+
+// Run shows the dashboard on t until q is pressed or ctx is done.
+func (d *Dashboard) Run(ctx context.Context, t *Terminal) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	keys := t.Keys()
+	results := make(chan fetchResult, len(d.panels))
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	d.refresh(ctx, results, time.Now(), true)
+
+	for {
+		if err := t.Draw(d.view(t.Size())); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case k, ok := <-keys:
+			if !ok {
+				return nil
+			}
+
+			switch d.handleKey(k) {
+			case actionQuit:
+				return nil
+			case actionRefresh:
+				d.refresh(ctx, results, time.Now(), true)
+			case actionNone:
+			}
+		case r := <-results:
+			p := d.panels[r.panel]
+			p.loading = false
+			p.err = r.err
+			p.next = time.Now().Add(p.backoff.Next(r.err))
+
+			if r.err == nil {
+				p.departures = r.departures
+				p.updated = time.Now()
+			}
+		case now := <-ticker.C:
+			// Redraw every second so countdowns tick and resizes apply.
+			d.refresh(ctx, results, now, false)
+		}
+	}
+}
+
+// refresh fetches the panels that are due, or all of them, unless they are
+// still loading. The requests run concurrently; the API client's rate
+// limiter spaces them out.
+func (d *Dashboard) refresh(ctx context.Context, results chan<- fetchResult, now time.Time, all bool) {
+	for i, p := range d.panels {
+		if p.loading || (!all && now.Before(p.next)) {
+			continue
+		}
+
+		p.loading = true
+		p.next = now.Add(d.interval)
+
+		go func() {
+			deps, err := p.Fetch(ctx)
+
+			select {
+			case results <- fetchResult{panel: i, departures: deps, err: err}:
+			case <-ctx.Done():
+			}
+		}()
+	}
+}
+
+// handleKey applies a key press.
+func (d *Dashboard) handleKey(k Key) action {
+	if d.editing {
+		switch k {
+		case KeyEnter:
+			d.editing = false
+			d.filter = strings.FieldsFunc(d.input, func(r rune) bool { return r == ',' || r == ' ' })
+		case KeyEsc, KeyCtrlC:
+			d.editing = false
+			d.input = ""
+			d.filter = nil
+		case KeyBackspace:
+			if d.input != "" {
+				_, size := utf8.DecodeLastRuneInString(d.input)
+				d.input = d.input[:len(d.input)-size]
+			}
+		default:
+			if utf8.RuneCountInString(string(k)) == 1 {
+				d.input += string(k)
+			}
+		}
+
+		return actionNone
+	}
+
+	switch k {
+	case "q", KeyCtrlC:
+		return actionQuit
+	case "r":
+		return actionRefresh
+	case KeyTab, KeyDown:
+		d.active = (d.active + 1) % len(d.panels)
+	case KeyBackTab, KeyUp:
+		d.active = (d.active + len(d.panels) - 1) % len(d.panels)
+	case "/":
+		d.editing = true
+		d.input = strings.Join(d.filter, ",")
+	case KeyEsc:
+		d.filter = nil
+	}
+
+	return actionNone
+}
+
+// view renders the dashboard as height lines of at most width columns.
+func (d *Dashboard) view(width, height int) []string {
+	now := time.Now()
+	bodyHeight := height - 1
+
+	cols := min(max(width/minPanelWidth, 1), len(d.panels))
+	rows := (len(d.panels) + cols - 1) / cols
+
+	panels := d.panels
+	first := 0
+
+	// Show only the active panel when the grid does not fit.
+	if rows*minPanelHeight > bodyHeight {
+		cols, rows = 1, 1
+		panels = d.panels[d.active : d.active+1]
+		first = d.active
+	}
+
+	panelWidth := (width - (cols - 1)) / cols
+	panelHeight := bodyHeight / rows
+
+	lines := make([]string, 0, height)
+
+	for row := range rows {
+		blocks := make([][]string, 0, cols)
+
+		for col := range cols {
+			i := row*cols + col
+			if i >= len(panels) {
+				blocks = append(blocks, blank(panelWidth, panelHeight))
+
+				continue
+			}
+
+			blocks = append(blocks, d.panelView(panels[i], first+i == d.active, panelWidth, panelHeight, now))
+		}
+
+		for y := range panelHeight {
+			parts := make([]string, len(blocks))
+			for i, b := range blocks {
+				parts[i] = b[y]
+			}
+
+			lines = append(lines, strings.Join(parts, output.Dim("│")))
+		}
+	}
+
+	for len(lines) < bodyHeight {
+		lines = append(lines, "")
+	}
+
+	return append(lines, d.footer(width, now))
+}
+
+func (d *Dashboard) panelView(p *Panel, active bool, width, height int, now time.Time) []string {
+	lines := make([]string, 0, height)
+
+	status := ""
+
+	switch {
+	case p.err != nil && !p.updated.IsZero():
+		status = "stale since " + output.FormatTime(p.updated) + " "
+	case p.err != nil:
+		status = "error "
+	case p.loading && p.updated.IsZero():
+		status = "loading "
+	case !p.updated.IsZero():
+		status = fmt.Sprintf("%ds ago ", int(now.Sub(p.updated).Seconds()))
+	}
+
+	title := fit(" "+p.Title, width-utf8.RuneCountInString(status)) + status
+	if active {
+		title = output.Style(title).Reverse().Bold().String()
+	} else {
+		title = output.Bold(title)
+	}
+
+	lines = append(lines, title)
+
+	// Row layout: " LINE DESTINATION IN DELAY", with fixed columns around the
+	// destination. Cells are fitted before styling, so widths stay exact.
+	destWidth := max(width-rowFixedWidth, 1)
+	pad := strings.Repeat(" ", max(width-rowFixedWidth-destWidth, 0))
+	lines = append(lines, output.Dim(fit(" "+fit("LINE", 4)+" "+fit("DESTINATION", destWidth)+" "+fitLeft("IN", 6)+" "+fit("DELAY", delayWidth), width)))
+
+	if p.err != nil {
+		lines = append(lines, output.Red(fit(" "+p.err.Error(), width)))
+	}
+
+	for _, dep := range p.departures {
+		if len(lines) >= height {
+			break
+		}
+
+		if !d.matches(dep) {
+			continue
+		}
+
+		expected := dep.ExpectedTime()
+		if expected.Before(now.Add(-time.Minute)) {
+			continue
+		}
+
+		line := output.Bold(fit(lineNumber(dep), 4))
+		dest := fit(dep.Destination, destWidth)
+		in := fitLeft(countdown(expected, now), 6)
+
+		delayText := "on time"
+		colour := output.DelayColor(dep.DelaySeconds())
+
+		switch {
+		case dep.IsCancelled():
+			delayText, colour = "CANCELLED", output.Red
+		case dep.SkipsStop():
+			delayText, colour = "SKIPPED", output.Red
+		case !dep.IsRealTime():
+			delayText, colour = "sched.", output.Dim
+		case dep.DelaySeconds() != 0:
+			delayText = delayLabel(dep.DelaySeconds())
+		}
+
+		lines = append(lines, " "+line+" "+dest+" "+in+" "+colour(fit(delayText, delayWidth))+pad)
+	}
+
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", width))
+	}
+
+	return lines[:height]
+}
+
+func (d *Dashboard) footer(width int, now time.Time) string {
+	if d.editing {
+		return fit(" filter lines: "+d.input+"_   enter apply · esc clear", width)
+	}
+
+	left := " tab next · / filter · r refresh · q quit"
+	if len(d.filter) > 0 {
+		left += " · lines " + strings.Join(d.filter, ",")
+	}
+
+	right := fmt.Sprintf("next refresh in %ds ", max(int(math.Ceil(d.nextRefresh().Sub(now).Seconds())), 0))
+
+	return output.Dim(fit(left, width-utf8.RuneCountInString(right)) + right)
+}
+
+// nextRefresh is when the next panel is fetched.
+func (d *Dashboard) nextRefresh() time.Time {
+	var next time.Time
+
+	for _, p := range d.panels {
+		if next.IsZero() || p.next.Before(next) {
+			next = p.next
+		}
+	}
+
+	return next
+}
+
+func (d *Dashboard) matches(dep api.Departure) bool {
+	if len(d.filter) == 0 {
+		return true
+	}
+
+	for _, line := range d.filter {
+		if strings.EqualFold(line, dep.LinePublicNumber) || line == strconv.Itoa(dep.LineNumber) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func lineNumber(d api.Departure) string {
+	if d.LinePublicNumber != "" {
+		return d.LinePublicNumber
+	}
+
+	return strconv.Itoa(d.LineNumber)
+}
+
+// countdown is the whole minutes until t, or the seconds in its last minute,
+// so the dashboard visibly ticks between fetches.
+func countdown(t, now time.Time) string {
+	diff := t.Sub(now)
+
+	switch {
+	case diff <= 0:
+		return "now"
+	case diff < time.Minute:
+		return fmt.Sprintf("%ds", int(diff.Seconds()))
+	case diff < time.Hour:
+		return fmt.Sprintf("%dm", int(diff.Minutes()))
+	default:
+		return output.FormatTime(t)
+	}
+}
+
+func delayLabel(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+
+	if seconds < 60 {
+		return fmt.Sprintf("%s%ds", sign, seconds)
+	}
+
+	return fmt.Sprintf("%s%dm", sign, seconds/60)
+}
+
+// fit pads or cuts s to exactly width runes, marking cuts with an ellipsis.
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	n := utf8.RuneCountInString(s)
+
+	switch {
+	case n == width:
+		return s
+	case n < width:
+		return s + strings.Repeat(" ", width-n)
+	}
+
+	runes := []rune(s)
+
+	return string(runes[:width-1]) + "…"
+}
+
+// fitLeft is fit with s aligned to the right.
+func fitLeft(s string, width int) string {
+	n := utf8.RuneCountInString(s)
+	if n >= width {
+		return fit(s, width)
+	}
+
+	return strings.Repeat(" ", width-n) + s
+}
+
+func blank(width, height int) []string {
+	lines := make([]string, height)
+	for i := range lines {
+		lines[i] = strings.Repeat(" ", width)
+	}
+
+	return lines
+}
@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dedene/delijn-cli/internal/api"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []Key
+	}{
+		{"q", []Key{"q"}},
+		{"\t", []Key{KeyTab}},
+		{"\x1b[Z", []Key{KeyBackTab}},
+		{"\x1b", []Key{KeyEsc}},
+		{"\x1b[A", []Key{KeyUp}},
+		{"\x1b[15~", nil},
+		{"12\r", []Key{"1", "2", KeyEnter}},
+		{"\x7f\x03", []Key{KeyBackspace, KeyCtrlC}},
+		{"é", []Key{"é"}},
+	}
+
+	for _, tt := range tests {
+		if got := parseKeys([]byte(tt.in)); !slices.Equal(got, tt.want) {
+			t.Errorf("parseKeys(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func testDashboard() *Dashboard {
+	now := time.Now()
+	deps := []api.Departure{
+		{LineNumber: 1, Destination: "Flanders Expo", ScheduledTime: now.Add(2 * time.Minute)},
+		{LineNumber: 4, LinePublicNumber: "4", Destination: "UZ Gent", ScheduledTime: now.Add(5 * time.Minute)},
+	}
+
+	return NewDashboard([]*Panel{
+		{Title: "@home", departures: deps, updated: now},
+		{Title: "@work", err: errors.New("boom")},
+		{Title: "@station", departures: deps, updated: now},
+	}, 30*time.Second)
+}
+
+func TestHandleKey(t *testing.T) {
+	d := testDashboard()
+
+	for _, k := range []Key{KeyTab, KeyTab, KeyTab} {
+		d.handleKey(k)
+	}
+
+	if d.active != 0 {
+		t.Errorf("three tabs over three panels: active = %d, want 0", d.active)
+	}
+
+	d.handleKey(KeyBackTab)
+
+	if d.active != 2 {
+		t.Errorf("shift+tab from the first panel: active = %d, want 2", d.active)
+	}
+
+	for _, k := range []Key{"/", "1", ",", "5", KeyBackspace, "4", KeyEnter} {
+		if got := d.handleKey(k); got != actionNone {
+			t.Errorf("handleKey(%q) while filtering = %v, want none", k, got)
+		}
+	}
+
+	if !slices.Equal(d.filter, []string{"1", "4"}) {
+		t.Errorf("filter = %v, want [1 4]", d.filter)
+	}
+
+	if got := d.handleKey("r"); got != actionRefresh {
+		t.Errorf("handleKey(r) = %v, want refresh", got)
+	}
+
+	if got := d.handleKey("q"); got != actionQuit {
+		t.Errorf("handleKey(q) = %v, want quit", got)
+	}
+}
+
+var escapes = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func TestViewFitsTerminal(t *testing.T) {
+	d := testDashboard()
+
+	for _, size := range [][2]int{{80, 24}, {160, 50}, {40, 6}, {30, 10}} {
+		width, height := size[0], size[1]
+
+		lines := d.view(width, height)
+		if len(lines) != height {
+			t.Errorf("%dx%d: %d lines, want %d", width, height, len(lines), height)
+		}
+
+		for i, line := range lines {
+			if n := utf8.RuneCountInString(escapes.ReplaceAllString(line, "")); n > width {
+				t.Errorf("%dx%d: line %d is %d columns wide: %q", width, height, i, n, line)
+			}
+		}
+	}
+}
+
+func TestCountdown(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		in   time.Duration
+		want string
+	}{
+		{-time.Second, "now"},
+		{45 * time.Second, "45s"},
+		{3*time.Minute + 20*time.Second, "3m"},
+	}
+
+	for _, tt := range tests {
+		if got := countdown(now.Add(tt.in), now); got != tt.want {
+			t.Errorf("countdown(%s) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStalePanelKeepsDepartures(t *testing.T) {
+	d := testDashboard()
+	d.panels[0].err = errors.New("circuit breaker is open")
+
+	lines := d.view(80, 24)
+	text := escapes.ReplaceAllString(strings.Join(lines, "\n"), "")
+
+	if !strings.Contains(text, "stale since") {
+		t.Error("stale panel has no stale marker")
+	}
+
+	if strings.Count(text, "Flanders Expo") != 2 {
+		t.Errorf("want the departures of both panels with data:\n%s", text)
+	}
+}
+
+func TestRefreshSkipsPanelsNotDue(t *testing.T) {
+	d := testDashboard()
+	now := time.Now()
+	fetch := func(context.Context) ([]api.Departure, error) { return nil, nil }
+
+	for _, p := range d.panels {
+		p.Fetch = fetch
+	}
+
+	d.panels[1].next = now.Add(time.Minute) // backing off
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d.refresh(ctx, make(chan fetchResult, len(d.panels)), now, false)
+
+	for i, want := range []bool{true, false, true} {
+		if d.panels[i].loading != want {
+			t.Errorf("panel %d loading = %v, want %v", i, d.panels[i].loading, want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// Key is a key press: a printable character, or one of the named keys.
+type Key string
+
+const (
+	KeyTab       Key = "tab"
+	KeyBackTab   Key = "shift+tab"
+	KeyEnter     Key = "enter"
+	KeyEsc       Key = "esc"
+	KeyBackspace Key = "backspace"
+	KeyCtrlC     Key = "ctrl+c"
+	KeyUp        Key = "up"
+	KeyDown      Key = "down"
+)
+
+// readKeys decodes key presses from r and sends them on keys. It closes keys
+// when r fails.
+func readKeys(r io.Reader, keys chan<- Key) {
+	defer close(keys)
+
+	buf := make([]byte, 64)
+
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for _, k := range parseKeys(buf[:n]) {
+			keys <- k
+		}
+	}
+}
+
+// parseKeys decodes one read from a raw terminal. A read holds a single
+// escape sequence or one or more characters, e.g. pasted text.
+func parseKeys(b []byte) []Key {
+	if len(b) > 1 && b[0] == 0x1b {
+		switch string(b[1:]) {
+		case "[Z":
+			return []Key{KeyBackTab}
+		case "[A", "OA":
+			return []Key{KeyUp}
+		case "[B", "OB":
+			return []Key{KeyDown}
+		}
+
+		return nil
+	}
+
+	var keys []Key
+
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		b = b[size:]
+
+		switch r {
+		case '\t':
+			keys = append(keys, KeyTab)
+		case '\r', '\n':
+			keys = append(keys, KeyEnter)
+		case 0x1b:
+			keys = append(keys, KeyEsc)
+		case 0x7f, 0x08:
+			keys = append(keys, KeyBackspace)
+		case 0x03:
+			keys = append(keys, KeyCtrlC)
+		default:
+			if r >= ' ' && r != utf8.RuneError {
+				keys = append(keys, Key(string(r)))
+			}
+		}
+	}
+
+	return keys
+}
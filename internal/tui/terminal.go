@@ -0,0 +1,108 @@
+// Package tui draws full-screen terminal views without a UI framework: the
+// terminal is put in raw mode, keys are read byte by byte and frames are
+// redrawn in place with cursor positioning, so nothing flickers.
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const (
+	altScreenOn  = "\x1b[?1049h"
+	altScreenOff = "\x1b[?1049l"
+	hideCursor   = "\x1b[?25l"
+	showCursor   = "\x1b[?25h"
+	cursorHome   = "\x1b[H"
+	clearLine    = "\x1b[K" // to the end of the line
+	clearBelow   = "\x1b[J" // from the cursor to the end of the screen
+)
+
+// Terminal is an interactive terminal in raw mode.
+type Terminal struct {
+	in        *os.File
+	out       *os.File
+	state     *term.State
+	altScreen bool
+}
+
+// Open puts in in raw mode and hides the cursor on out. With altScreen the
+// view is drawn on the alternate screen, which is restored on Close.
+func Open(in, out *os.File, altScreen bool) (*Terminal, error) {
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return nil, fmt.Errorf("enter raw mode: %w", err)
+	}
+
+	t := &Terminal{in: in, out: out, state: state, altScreen: altScreen}
+
+	if altScreen {
+		fmt.Fprint(out, altScreenOn)
+	}
+
+	fmt.Fprint(out, hideCursor)
+
+	return t, nil
+}
+
+// Close shows the cursor and restores the terminal.
+func (t *Terminal) Close() error {
+	fmt.Fprint(t.out, showCursor)
+
+	if t.altScreen {
+		fmt.Fprint(t.out, altScreenOff)
+	}
+
+	if err := term.Restore(int(t.in.Fd()), t.state); err != nil {
+		return fmt.Errorf("restore terminal: %w", err)
+	}
+
+	return nil
+}
+
+// Size returns the width and height of the terminal, or 80x24 when unknown.
+func (t *Terminal) Size() (int, int) {
+	w, h, err := term.GetSize(int(t.out.Fd()))
+	if err != nil || w <= 0 || h <= 0 {
+		return 80, 24
+	}
+
+	return w, h
+}
+
+// Draw replaces the screen with lines, overwriting in place rather than
+// clearing first. Lines must not be wider than the terminal.
+func (t *Terminal) Draw(lines []string) error {
+	var b strings.Builder
+
+	b.WriteString(cursorHome)
+
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+
+		b.WriteString(line)
+		b.WriteString(clearLine)
+	}
+
+	b.WriteString(clearBelow)
+
+	if _, err := t.out.WriteString(b.String()); err != nil {
+		return fmt.Errorf("draw: %w", err)
+	}
+
+	return nil
+}
+
+// Keys reads key presses from the terminal until it is closed.
+func (t *Terminal) Keys() <-chan Key {
+	keys := make(chan Key)
+
+	go readKeys(t.in, keys)
+
+	return keys
+}
@@ -0,0 +1,226 @@
+// Package web serves a live departure board page. One poller refreshes the
+// departures and pushes them to every open page over Server-Sent Events.
+package web
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dedene/delijn-cli/internal/api"
+	"github.com/dedene/delijn-cli/internal/schema"
+)
+
+//go:embed static
+var static embed.FS
+
+// keepAlive is how often an idle event stream gets a comment, so proxies do
+// not close it.
+const keepAlive = 20 * time.Second
+
+// Document is what the page shows, sent as the data of an update event.
+type Document struct {
+	Updated         time.Time `json:"updated"`
+	IntervalSeconds int       `json:"interval_seconds"`
+	Stops           []Stop    `json:"stops"`
+}
+
+// Stop is a stop on the board. Error is set instead of Departures when its
+// departures could not be fetched.
+type Stop struct {
+	Title      string      `json:"title"`
+	Stop       int         `json:"stop"`
+	Error      string      `json:"error,omitempty"`
+	Departures []Departure `json:"departures"`
+}
+
+// Departure is a departure with the colours of its line, as "#rrggbb".
+// Lines without known colours have none.
+type Departure struct {
+	schema.Departure
+
+	Foreground string `json:"foreground,omitempty"`
+	Background string `json:"background,omitempty"`
+}
+
+// Board polls with fetch and streams the documents it returns to the open
+// pages. It only polls while at least one page is open.
+type Board struct {
+	fetch    func(ctx context.Context) (Document, error)
+	interval time.Duration
+
+	mu      sync.Mutex
+	clients map[chan []byte]bool
+	last    []byte        // last event, sent to pages as they open
+	wake    chan struct{} // signalled when the first page opens
+}
+
+// NewBoard returns a board that refreshes every interval with fetch.
+func NewBoard(fetch func(ctx context.Context) (Document, error), interval time.Duration) *Board {
+	return &Board{
+		fetch:    fetch,
+		interval: interval,
+		clients:  map[chan []byte]bool{},
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Handler serves the page at / and the event stream at /events.
+func (b *Board) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	page, _ := fs.Sub(static, "static")
+	mux.Handle("GET /", http.FileServerFS(page))
+	mux.HandleFunc("GET /events", b.serveEvents)
+
+	return mux
+}
+
+// Run polls until ctx is done. A failed refresh is sent as an error event;
+// the pages keep showing the last departures, marked stale. Refreshes back
+// off while the API is throttled.
+func (b *Board) Run(ctx context.Context) {
+	backoff := api.NewBackoff(b.interval)
+
+	for {
+		if b.idle() {
+			select {
+			case <-ctx.Done():
+				return
+			case <-b.wake:
+			}
+		}
+
+		fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		doc, err := b.fetch(fetchCtx)
+
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			b.publish(event("error", map[string]string{"error": err.Error()}), false)
+		} else {
+			doc.IntervalSeconds = int(b.interval.Seconds())
+			b.publish(event("update", doc), true)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff.Next(err)):
+		}
+	}
+}
+
+func (b *Board) idle() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.clients) == 0
+}
+
+// publish sends msg to every page. An update is also kept for pages that
+// open later.
+func (b *Board) publish(msg []byte, keep bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if keep {
+		b.last = msg
+	}
+
+	for ch := range b.clients {
+		select {
+		case ch <- msg:
+		default:
+			// The page has not read the previous event yet; it gets the
+			// next one.
+		}
+	}
+}
+
+func (b *Board) subscribe() chan []byte {
+	ch := make(chan []byte, 1)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.last != nil {
+		ch <- b.last
+	}
+
+	b.clients[ch] = true
+
+	if len(b.clients) == 1 {
+		select {
+		case b.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	return ch
+}
+
+func (b *Board) unsubscribe(ch chan []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.clients, ch)
+}
+
+func (b *Board) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ch := b.subscribe()
+	defer b.unsubscribe(ch)
+
+	ping := time.NewTicker(keepAlive)
+	defer ping.Stop()
+
+	for {
+		var msg []byte
+
+		select {
+		case <-r.Context().Done():
+			return
+		case msg = <-ch:
+		case <-ping.C:
+			msg = []byte(": ping\n\n")
+		}
+
+		if _, err := w.Write(msg); err != nil {
+			return
+		}
+
+		flusher.Flush()
+	}
+}
+
+// event formats an SSE event with data encoded as JSON.
+func event(name string, data any) []byte {
+	body, err := json.Marshal(data)
+	if err != nil {
+		body, _ = json.Marshal(map[string]string{"error": fmt.Sprintf("encode %s: %v", name, err)})
+		name = "error"
+	}
+
+	return []byte("event: " + name + "\ndata: " + string(body) + "\n\n")
+}
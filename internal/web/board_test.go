@@ -0,0 +1,116 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// readEvent reads the next event from an SSE stream, skipping comments.
+func readEvent(t *testing.T, r *bufio.Reader) (name, data string) {
+	t.Helper()
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "" && name != "":
+			return name, data
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func openEvents(t *testing.T, srv *httptest.Server) *bufio.Reader {
+	t.Helper()
+
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { resp.Body.Close() })
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	return bufio.NewReader(resp.Body)
+}
+
+func TestBoardStreamsUpdates(t *testing.T) {
+	var calls atomic.Int32
+
+	board := NewBoard(func(context.Context) (Document, error) {
+		n := calls.Add(1)
+		if n == 2 {
+			return Document{}, errors.New("API down")
+		}
+
+		return Document{Stops: []Stop{{Title: "@home", Stop: 200144}}}, nil
+	}, 200*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go board.Run(ctx)
+
+	// Without pages the board does not poll.
+	time.Sleep(50 * time.Millisecond)
+
+	if n := calls.Load(); n != 0 {
+		t.Fatalf("fetched %d times with no page open", n)
+	}
+
+	// Registered before the streams are opened, so their bodies are closed
+	// first and Close does not wait for them.
+	srv := httptest.NewServer(board.Handler())
+	t.Cleanup(srv.Close)
+
+	first := openEvents(t, srv)
+
+	if name, data := readEvent(t, first); name != "update" || !strings.Contains(data, `"title":"@home"`) {
+		t.Errorf("first event = %s %s", name, data)
+	}
+
+	if name, data := readEvent(t, first); name != "error" || data != `{"error":"API down"}` {
+		t.Errorf("second event = %s %s", name, data)
+	}
+
+	// A page that opens later starts from the last update, not the error.
+	if name, data := readEvent(t, openEvents(t, srv)); name != "update" || !strings.Contains(data, `"title":"@home"`) {
+		t.Errorf("event for a new page = %s %s", name, data)
+	}
+}
+
+func TestBoardServesPage(t *testing.T) {
+	srv := httptest.NewServer(NewBoard(nil, time.Second).Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `new EventSource("events")`) {
+		t.Errorf("GET / = %d, body:\n%.200s", resp.StatusCode, body)
+	}
+}